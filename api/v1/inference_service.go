@@ -17,7 +17,11 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -28,27 +32,569 @@ type InferenceServiceSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// +kubebuilder:validation:MinLength=0
+	// +kubebuilder:validation:Enum=kfserving;seldon
 
 	// The backend defines which service will be used to serve the model
-	// e.g. kfserving or seldon[_mlfow|sklearn]
-	Backend string `json:"backend"`
-
-	// +kubebuilder:validation:MinLength=0
+	// i.e. kfserving or seldon. The enum lists the backends registered by
+	// the controller and must be updated when adding a backend.
+	// Defaults to the backend set in the operator configuration
+	// +optional
+	Backend string `json:"backend,omitempty"`
 
 	// The URI where the trained model is stored
-	// e.g. an s3 uri
-	ModelUri string `json:"modelUri"`
+	// e.g. an s3 uri. Required unless the model variants, or the model nodes of the
+	// inference graph, all set the URI of their own model
+	// +optional
+	ModelUri string `json:"modelUri,omitempty"`
 
-	// The service account used to run the inference service
+	// The service account used to run the inference service. The credentials
+	// to download the model are set with spec.storage
 	// +optional
 	ServiceAccountName string `json:"serviceAccountName"`
+
+	// The framework used to train the model, which selects the model server
+	// used by the backend, e.g. sklearn or tensorflow. Defaults to the framework
+	// set in the operator configuration
+	// +optional
+	Framework Framework `json:"framework,omitempty"`
+
+	// Compute resources of the predictor container.
+	// Defaults to the resources set in the operator configuration
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Autoscaling settings of the predictor
+	// +optional
+	Scaling *ScalingSpec `json:"scaling,omitempty"`
+
+	// A candidate model receiving a share of the traffic, to be promoted or aborted
+	// with the canary action annotation. It can only be added to a deployed service
+	// and its model cannot change until the rollout ends.
+	// +optional
+	Canary *CanarySpec `json:"canary,omitempty"`
+
+	// A candidate model receiving a copy of the traffic, whose responses are discarded.
+	// Its readiness is reported by the ShadowReady condition, which is False with the
+	// Unsupported reason when the backend serves the stable model without it.
+	// +optional
+	Shadow *ShadowSpec `json:"shadow,omitempty"`
+
+	// Variants of the model sharing the traffic for A/B testing. The variants replace
+	// the model stored at modelUri, and their weights sum to 100
+	// +optional
+	Variants []VariantSpec `json:"variants,omitempty"`
+
+	// The storage holding the model and the credentials needed to download it
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// The inference protocol served by the predictor, i.e. V1, V2 (Open Inference
+	// Protocol) or gRPC. Defaults to the protocol set in the operator configuration,
+	// or to the default protocol of the model server
+	// +optional
+	Protocol Protocol `json:"protocol,omitempty"`
+
+	// Overrides the version or the image of the model server. Defaults to the runtime
+	// set for the backend and framework in the operator configuration
+	// +optional
+	Runtime *RuntimeSpec `json:"runtime,omitempty"`
+
+	// A custom model server replacing the one of the framework. The model stored at
+	// modelUri is downloaded for it to /mnt/models by the storage initializer of the
+	// backend, and the runtime settings do not apply to it
+	// +optional
+	Container *corev1.Container `json:"container,omitempty"`
+
+	// Environment variables set in the container serving the model
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Sources of environment variables set in the container serving the model,
+	// e.g. config maps or secrets
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
+	// Volumes added to the pod serving the model
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// Volumes mounted into the container serving the model, which must be listed in volumes
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// Constraints on the nodes running the model. The fields left unset default to the
+	// scheduling profile set in the operator configuration
+	// +optional
+	Scheduling *SchedulingSpec `json:"scheduling,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+
+	// The maximum time in seconds to serve a request. Defaults to 60 for the kfserving
+	// backend and to the seldon default for the seldon backend
+	// +optional
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+
+	// Parameters passed to the model server, e.g. method: predict_proba for sklearn
+	// models served by seldon. The model server of the backend must support the keys,
+	// custom containers get all of them as command line arguments or graph parameters
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// Backend specific settings not modelled by this API, applied on top of the
+	// generated backend spec
+	// +optional
+	Overrides *OverridesSpec `json:"overrides,omitempty"`
+
+	// A container pre-processing the requests before they reach the predictor and
+	// post-processing its responses
+	// +optional
+	Transformer *TransformerSpec `json:"transformer,omitempty"`
+
+	// An Alibi explainer explaining the predictions of the model
+	// +optional
+	Explainer *ExplainerSpec `json:"explainer,omitempty"`
+
+	// Captures the requests and responses of the predictor
+	// +optional
+	Logging *LoggingSpec `json:"logging,omitempty"`
+
+	// The nodes of an inference graph chaining several models. The first node receives
+	// the requests, the other nodes are reached through the children of their parent.
+	// +optional
+	Graph []GraphNode `json:"graph,omitempty"`
+}
+
+// GraphNodeType is the role of a node in the inference graph
+// +kubebuilder:validation:Enum=MODEL;TRANSFORMER;OUTPUT_TRANSFORMER;COMBINER;ROUTER
+type GraphNodeType string
+
+const (
+	GraphNodeModel             GraphNodeType = "MODEL"
+	GraphNodeTransformer       GraphNodeType = "TRANSFORMER"
+	GraphNodeOutputTransformer GraphNodeType = "OUTPUT_TRANSFORMER"
+	GraphNodeCombiner          GraphNodeType = "COMBINER"
+	GraphNodeRouter            GraphNodeType = "ROUTER"
+)
+
+// GraphNode is a node of the inference graph
+type GraphNode struct {
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+
+	// The name of the node, unique in the graph
+	Name string `json:"name"`
+
+	// The role of the node, defaults to MODEL
+	// +optional
+	Type GraphNodeType `json:"type,omitempty"`
+
+	// The URI of the model served by the node. Defaults to spec.modelUri for the
+	// model nodes
+	// +optional
+	ModelUri string `json:"modelUri,omitempty"`
+
+	// The framework of the model, which selects the model server of a model node
+	// without image. Defaults to spec.framework
+	// +optional
+	Framework Framework `json:"framework,omitempty"`
+
+	// The image serving the node instead of a model server of the backend, required
+	// for the nodes other than MODEL
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// The names of the nodes the node passes the requests to
+	// +optional
+	Children []string `json:"children,omitempty"`
+}
+
+// GetType returns the role of the node, falling back to MODEL
+func (n *GraphNode) GetType() GraphNodeType {
+	if n.Type == "" {
+		return GraphNodeModel
+	}
+	return n.Type
+}
+
+// LoggingMode selects the payloads captured
+// +kubebuilder:validation:Enum=all;request;response
+type LoggingMode string
+
+const (
+	LoggingAll      LoggingMode = "all"
+	LoggingRequest  LoggingMode = "request"
+	LoggingResponse LoggingMode = "response"
+)
+
+// LoggingSpec defines where the payloads sent to and returned by the predictor are captured
+type LoggingSpec struct {
+	// The payloads captured, defaults to all
+	// +optional
+	Mode LoggingMode `json:"mode,omitempty"`
+
+	// The URL of the sink receiving the payloads as CloudEvents. Defaults to the sink of
+	// the operator configuration, or to the sink of the backend when none is configured
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// GetMode returns the payloads captured, falling back to all
+func (l *LoggingSpec) GetMode() LoggingMode {
+	if l.Mode == "" {
+		return LoggingAll
+	}
+	return l.Mode
+}
+
+// ExplainerType is the Alibi explanation method
+// +kubebuilder:validation:Enum=anchor_tabular;anchor_images;anchor_text;counterfactuals;contrastive;kernel_shap;integrated_gradients;ale;tree_shap
+type ExplainerType string
+
+const (
+	ExplainerAnchorTabular       ExplainerType = "anchor_tabular"
+	ExplainerAnchorImages        ExplainerType = "anchor_images"
+	ExplainerAnchorText          ExplainerType = "anchor_text"
+	ExplainerCounterfactuals     ExplainerType = "counterfactuals"
+	ExplainerContrastive         ExplainerType = "contrastive"
+	ExplainerKernelShap          ExplainerType = "kernel_shap"
+	ExplainerIntegratedGradients ExplainerType = "integrated_gradients"
+	ExplainerALE                 ExplainerType = "ale"
+	ExplainerTreeShap            ExplainerType = "tree_shap"
+)
+
+// ExplainerSpec defines the Alibi explainer of the model
+type ExplainerSpec struct {
+	// The explanation method
+	Type ExplainerType `json:"type"`
+
+	// The URI of the trained explainer, fetched with the storage credentials of the model.
+	// Explainers that do not need training leave it unset.
+	// +optional
+	StorageUri string `json:"storageUri,omitempty"`
+
+	// Settings of the explainer passed to the Alibi server
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
+}
+
+// TransformerSpec defines the container transforming the requests and responses of the predictor
+type TransformerSpec struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// The image of the transformer
+	Image string `json:"image"`
+
+	// Environment variables set in the transformer container
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// The compute resources of the transformer, left to the backend defaults when unset
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// OverridesSpec holds, for each backend, a strategic merge patch applied to the spec of the
+// backend resource generated for the inference service. Lists without a merge key, such
+// as the seldon predictors, are replaced as a whole.
+type OverridesSpec struct {
+	// Patch of the KFServing InferenceService spec
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	KFServing *runtime.RawExtension `json:"kfserving,omitempty"`
+
+	// Patch of the SeldonDeployment spec
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Seldon *runtime.RawExtension `json:"seldon,omitempty"`
+}
+
+// ForBackend returns the patch for the named backend, nil when it has none
+func (o *OverridesSpec) ForBackend(backend string) *runtime.RawExtension {
+	if o == nil {
+		return nil
+	}
+	switch backend {
+	case "kfserving":
+		return o.KFServing
+	case "seldon":
+		return o.Seldon
+	}
+	return nil
+}
+
+// SchedulingSpec defines where the pods serving the model are scheduled
+type SchedulingSpec struct {
+	// Labels the nodes must have to run the pods
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Taints of the nodes tolerated by the pods
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity of the pods to nodes and to other pods
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// The priority class of the pods
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// RuntimeSpec selects the model server release serving the model
+type RuntimeSpec struct {
+	// The version of the model server, used as the image tag by the backends that
+	// select the image themselves
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// The image of the model server, which takes precedence over the version.
+	// The image must be allowed by the operator configuration
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=V1;V2;gRPC
+
+// Protocol is the inference protocol served by the predictor
+type Protocol string
+
+// Supported Protocol values
+const (
+	ProtocolV1   Protocol = "V1"
+	ProtocolV2   Protocol = "V2"
+	ProtocolGRPC Protocol = "gRPC"
+)
+
+// +kubebuilder:validation:Enum=s3;gcs;azure;http;pvc
+
+// StorageProvider is the kind of storage holding the model
+type StorageProvider string
+
+// Storage providers
+const (
+	StorageProviderS3    StorageProvider = "s3"
+	StorageProviderGCS   StorageProvider = "gcs"
+	StorageProviderAzure StorageProvider = "azure"
+	StorageProviderHTTP  StorageProvider = "http"
+	StorageProviderPVC   StorageProvider = "pvc"
+)
+
+// Keys expected in the storage credentials secret, for each provider
+const (
+	// s3: the access key pair
+	StorageS3AccessKeyID     = "AWS_ACCESS_KEY_ID"
+	StorageS3SecretAccessKey = "AWS_SECRET_ACCESS_KEY"
+	// gcs: the service account key file
+	StorageGCSCredentials = "gcloud-application-credentials.json"
+	// azure: the service principal
+	StorageAzureSubscriptionID = "AZ_SUBSCRIPTION_ID"
+	StorageAzureTenantID       = "AZ_TENANT_ID"
+	StorageAzureClientID       = "AZ_CLIENT_ID"
+	StorageAzureClientSecret   = "AZ_CLIENT_SECRET"
+	// http: the host and the headers sent to it
+	StorageHTTPHost    = "https-host"
+	StorageHTTPHeaders = "headers"
+)
+
+// StorageSpec defines where the model is stored and how to access it
+type StorageSpec struct {
+	// The storage provider, i.e. s3, gcs, azure, http or pvc
+	Provider StorageProvider `json:"provider"`
+
+	// The endpoint of the storage API, e.g. the url of a MinIO server.
+	// Only supported by the s3 provider
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// The secret holding the credentials for the storage, using the keys of the provider:
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY for s3, gcloud-application-credentials.json
+	// for gcs, AZ_SUBSCRIPTION_ID, AZ_TENANT_ID, AZ_CLIENT_ID and AZ_CLIENT_SECRET for azure,
+	// https-host and headers for http
+	// +optional
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// uriSchemes returns the model URI schemes served by the storage provider
+func (p StorageProvider) uriSchemes() []string {
+	switch p {
+	case StorageProviderS3:
+		return []string{"s3"}
+	case StorageProviderGCS:
+		return []string{"gs"}
+	case StorageProviderAzure, StorageProviderHTTP:
+		return []string{"https", "http"}
+	case StorageProviderPVC:
+		return []string{"pvc"}
+	}
+	return nil
+}
+
+// CanarySpec defines a candidate model rolled out next to the stable one
+type CanarySpec struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// The URI where the candidate model is stored
+	ModelUri string `json:"modelUri"`
+
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+
+	// The percentage of the traffic routed to the candidate model
+	TrafficPercent int32 `json:"trafficPercent"`
+}
+
+// ShadowSpec defines a candidate model mirroring the traffic of the stable one
+type ShadowSpec struct {
+	// +kubebuilder:validation:MinLength=1
+
+	// The URI where the candidate model is stored
+	ModelUri string `json:"modelUri"`
+}
+
+// VariantSpec defines a variant of the model receiving a share of the traffic
+type VariantSpec struct {
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+
+	// The name of the variant, unique in the service
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:MinLength=1
+
+	// The URI where the model of the variant is stored
+	ModelUri string `json:"modelUri"`
+
+	// The framework used to train the model of the variant. Defaults to spec.framework
+	// +optional
+	Framework Framework `json:"framework,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+
+	// The percentage of the traffic routed to the variant
+	Weight int32 `json:"weight"`
+}
+
+// CanaryActionAnnotationKey is the annotation requesting the controller to end a canary
+// rollout. The controller removes it once the action is applied to the spec.
+const CanaryActionAnnotationKey = "serving.fuseml.suse/canary-action"
+
+// Canary actions
+const (
+	// CanaryActionPromote replaces the stable model with the candidate one
+	CanaryActionPromote = "promote"
+	// CanaryActionAbort sends all the traffic back to the stable model
+	CanaryActionAbort = "abort"
+)
+
+// ScalingSpec defines how the predictor replicas are autoscaled
+type ScalingSpec struct {
+	// Minimum number of replicas, 0 enables scale to zero on the backends supporting it.
+	// Defaults to 1
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// Maximum number of replicas. Defaults to the minimum number of replicas
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+
+	// The metric driving the autoscaler. Defaults to the backend native metric
+	// +optional
+	Metric ScalingMetric `json:"metric,omitempty"`
+
+	// The target value of the metric for each replica, i.e. the number of concurrent
+	// requests, requests per second or percentage of the requested CPU
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Target *int32 `json:"target,omitempty"`
+}
+
+// ScalingMetric is a metric used to autoscale the predictor
+// +kubebuilder:validation:Enum=concurrency;rps;cpu
+type ScalingMetric string
+
+// Supported ScalingMetric values
+const (
+	ScalingMetricConcurrency ScalingMetric = "concurrency"
+	ScalingMetricRPS         ScalingMetric = "rps"
+	ScalingMetricCPU         ScalingMetric = "cpu"
+)
+
+// Validate checks that the replica bounds are consistent
+func (s *ScalingSpec) Validate() error {
+	if s.GetMinReplicas() > s.GetMaxReplicas() {
+		return fmt.Errorf("minReplicas (%d) cannot be greater than maxReplicas (%d)", s.GetMinReplicas(), s.GetMaxReplicas())
+	}
+	return nil
+}
+
+// GetMinReplicas returns the minimum number of replicas, defaulting to 1
+func (s *ScalingSpec) GetMinReplicas() int32 {
+	if s == nil || s.MinReplicas == nil {
+		return 1
+	}
+	return *s.MinReplicas
+}
+
+// GetMaxReplicas returns the maximum number of replicas, defaulting to the minimum
+func (s *ScalingSpec) GetMaxReplicas() int32 {
+	if s == nil || s.MaxReplicas == nil {
+		return s.GetMinReplicas()
+	}
+	return *s.MaxReplicas
+}
+
+// Framework is the machine learning framework a model was trained with
+// +kubebuilder:validation:Enum=sklearn;xgboost;lightgbm;tensorflow;pytorch;onnx;triton;mlflow
+type Framework string
+
+// Supported Framework values
+const (
+	FrameworkSKLearn    Framework = "sklearn"
+	FrameworkXGBoost    Framework = "xgboost"
+	FrameworkLightGBM   Framework = "lightgbm"
+	FrameworkTensorflow Framework = "tensorflow"
+	FrameworkPyTorch    Framework = "pytorch"
+	FrameworkONNX       Framework = "onnx"
+	FrameworkTriton     Framework = "triton"
+	FrameworkMLflow     Framework = "mlflow"
+)
+
+// DefaultFramework is the framework assumed when none is set in the spec
+const DefaultFramework = FrameworkSKLearn
+
+// RequiresModelUri returns true when spec.modelUri is served, i.e. the spec has no model
+// variants and its inference graph, if any, has model nodes without a model of their own
+func (s *InferenceServiceSpec) RequiresModelUri() bool {
+	if len(s.Variants) > 0 {
+		return false
+	}
+	if len(s.Graph) == 0 {
+		return true
+	}
+	for _, node := range s.Graph {
+		if node.GetType() == GraphNodeModel && node.ModelUri == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFramework returns the framework of the model, falling back to DefaultFramework
+func (s *InferenceServiceSpec) GetFramework() Framework {
+	if s.Framework == "" {
+		return DefaultFramework
+	}
+	return s.Framework
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
 // +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".status.url"
+// +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".status.replicas"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:resource:path=inferenceservices,shortName=fsvc
 
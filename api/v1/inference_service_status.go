@@ -19,6 +19,7 @@ package v1
 import (
 	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
 	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	corev1 "k8s.io/api/core/v1"
 	"knative.dev/pkg/apis"
 )
 
@@ -36,8 +37,143 @@ type InferenceServiceStatus struct {
 	// It generally has the form http[s]://{route-name}.{route-namespace}.{cluster-level-suffix}
 	// +optional
 	URL *apis.URL `json:"url,omitempty"`
+
+	// The path of the inference endpoint relative to the URL, or the full name of the
+	// inference method when the predictor serves the gRPC protocol
+	// +optional
+	InferencePath string `json:"inferencePath,omitempty"`
+
+	// The URL of the explain endpoint, set when the service has an explainer
+	// +optional
+	ExplainURL *apis.URL `json:"explainUrl,omitempty"`
+
+	// Current number of replicas of the predictor serving the stable model, or the first
+	// model variant, not counting the replicas of the canary, shadow or explainer
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// The split of the traffic between the stable and the canary models, or between
+	// the model variants
+	// +optional
+	Traffic []TrafficStatus `json:"traffic,omitempty"`
+
+	// The backend currently serving the model
+	// +optional
+	Backend string `json:"backend,omitempty"`
+
+	// The progress of the last switch to a different backend
+	// +optional
+	Migration *MigrationStatus `json:"migration,omitempty"`
+
+	// A brief CamelCase reason for the current state, set when the service failed
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// A human readable message with details about the current state
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The generation of the inference service spec last processed by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The readiness of the components serving the model, i.e. predictor, transformer,
+	// explainer and shadow
+	// +optional
+	Components map[ComponentType]ComponentStatus `json:"components,omitempty"`
+
+	// The readiness of the nodes of the inference graph, by node name
+	// +optional
+	Nodes map[string]ComponentStatus `json:"nodes,omitempty"`
+
+	// The readiness of the model variants, by variant name
+	// +optional
+	Variants map[string]ComponentStatus `json:"variants,omitempty"`
+
+	// The latest observations of the service state, i.e. Ready, BackendResourceCreated,
+	// PredictorReady and IngressReady, and ShadowReady for services with a shadow model
+	// +optional
+	Conditions apis.Conditions `json:"conditions,omitempty"`
+}
+
+// TrafficStatus reports the share of the traffic routed to a model
+type TrafficStatus struct {
+	// The traffic target, i.e. stable or canary
+	Name string `json:"name"`
+
+	// The percentage of the traffic routed to the target
+	Percent int64 `json:"percent"`
+}
+
+// Traffic target names
+const (
+	TrafficTargetStable = "stable"
+	TrafficTargetCanary = "canary"
+)
+
+// ComponentType names a component of the inference service
+type ComponentType string
+
+// Inference service components
+const (
+	ComponentPredictor   ComponentType = "predictor"
+	ComponentTransformer ComponentType = "transformer"
+	ComponentExplainer   ComponentType = "explainer"
+	ComponentShadow      ComponentType = "shadow"
+)
+
+// ComponentStatus reports the readiness of a component of the inference service
+type ComponentStatus struct {
+	// Whether the component is ready, one of True, False or Unknown
+	Ready corev1.ConditionStatus `json:"ready"`
+
+	// A brief CamelCase reason for the readiness, set when the component is not ready
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// A human readable message with details about the readiness
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The url serving the component, when it is exposed on its own
+	// +optional
+	URL *apis.URL `json:"url,omitempty"`
+}
+
+// MigrationStatus reports the progress of a switch to a different backend
+type MigrationStatus struct {
+	// The backend serving the model before the migration
+	From string `json:"from"`
+
+	// The backend requested in the spec
+	To string `json:"to"`
+
+	// The current phase of the migration
+	Phase MigrationPhase `json:"phase"`
+
+	// A brief CamelCase reason for the phase, set when the migration failed
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// A human readable message with details about the phase
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
+type MigrationPhase string
+
+// Migration phases
+const (
+	// The new backend is being deployed while the previous one keeps serving the model
+	MigrationPhaseProvisioning MigrationPhase = "Provisioning"
+	// The new backend is serving the model and the previous one was deleted
+	MigrationPhaseCompleted MigrationPhase = "Completed"
+	// The new backend failed to deploy, the previous one keeps serving the model
+	MigrationPhaseFailed MigrationPhase = "Failed"
+	// The spec was reverted to the previous backend before the migration completed
+	MigrationPhaseAborted MigrationPhase = "Aborted"
+)
+
 type StatusState string
 
 // CRD Status values
@@ -47,39 +183,280 @@ const (
 	StatusStateFailed    StatusState = "Failed"
 )
 
-func (ss *InferenceServiceStatus) PropagateStatusFromKfserving(serviceStatus *kfservingv1.InferenceServiceStatus) {
-	// propagate overall service condition
-	if len(serviceStatus.Status.Conditions) <= 0 {
+// Reasons reported when the backend resource fails without giving a reason of its own
+const (
+	ReasonBackendFailed    = "BackendFailed"
+	ReasonDeploymentFailed = "DeploymentFailed"
+)
+
+// Condition types reported by the inference service, in addition to the Ready condition
+// which is True once all of them are True
+const (
+	// The backend resource serving the model was created or updated from the spec
+	BackendResourceCreated apis.ConditionType = "BackendResourceCreated"
+	// The predictor serving the model is ready
+	PredictorReady apis.ConditionType = "PredictorReady"
+	// The endpoint routing the requests to the predictor is ready
+	IngressReady apis.ConditionType = "IngressReady"
+)
+
+// ShadowReady reports that the shadow model mirroring the traffic is ready. It is only set
+// for services with a shadow model and is not part of the Ready condition, so that the
+// stable model keeps being served when the shadow is not.
+const ShadowReady apis.ConditionType = "ShadowReady"
+
+// ReasonUnsupported is reported when the backend cannot deploy an optional component
+const ReasonUnsupported = "Unsupported"
+
+var conditionSet = apis.NewLivingConditionSet(
+	BackendResourceCreated,
+	PredictorReady,
+	IngressReady,
+)
+
+// GetConditions implements apis.ConditionsAccessor
+func (ss *InferenceServiceStatus) GetConditions() apis.Conditions {
+	return ss.Conditions
+}
+
+// SetConditions implements apis.ConditionsAccessor
+func (ss *InferenceServiceStatus) SetConditions(conditions apis.Conditions) {
+	ss.Conditions = conditions
+}
+
+// InitializeConditions sets the conditions that are not reported yet to Unknown
+func (ss *InferenceServiceStatus) InitializeConditions() {
+	conditionSet.Manage(ss).InitializeConditions()
+}
+
+// GetCondition returns the condition of the given type, or nil when it is not reported
+func (ss *InferenceServiceStatus) GetCondition(t apis.ConditionType) *apis.Condition {
+	return conditionSet.Manage(ss).GetCondition(t)
+}
+
+// IsReady returns true when the Ready condition is True
+func (ss *InferenceServiceStatus) IsReady() bool {
+	return conditionSet.Manage(ss).IsHappy()
+}
+
+// updateState sets the state of the service from its Ready condition
+func (ss *InferenceServiceStatus) updateState() {
+	ready := conditionSet.Manage(ss).GetTopLevelCondition()
+	switch {
+	case ss.IsReady():
+		ss.Status = StatusStateAvailable
+	case ready != nil && ready.Status == corev1.ConditionFalse:
+		ss.Status = StatusStateFailed
+	default:
 		ss.Status = StatusStateCreating
-	} else {
-		status := serviceStatus.Status.Conditions[0].Status
-		switch status {
-		case "True":
-			if serviceStatus.Address != nil {
-				ss.Status = StatusStateAvailable
-				ss.URL = serviceStatus.URL
-			} else {
-				ss.Status = StatusStateCreating
+	}
+}
+
+// MarkBackendResourceCreated reports that the backend resource matches the spec
+func (ss *InferenceServiceStatus) MarkBackendResourceCreated() {
+	conditionSet.Manage(ss).MarkTrue(BackendResourceCreated)
+}
+
+// MarkFailed sets the service as failed for the given reason
+func (ss *InferenceServiceStatus) MarkFailed(reason, message string) {
+	ss.Status = StatusStateFailed
+	ss.Reason = reason
+	ss.Message = message
+	conditionSet.Manage(ss).MarkFalse(BackendResourceCreated, reason, "%s", message)
+}
+
+// MarkShadowUnsupported reports that the backend serves the stable model without the
+// shadow model of the spec
+func (ss *InferenceServiceStatus) MarkShadowUnsupported(message string) {
+	ss.PropagateShadowStatus(ComponentStatus{
+		Ready:   corev1.ConditionFalse,
+		Reason:  ReasonUnsupported,
+		Message: message,
+	})
+}
+
+// PropagateShadowStatus sets the ShadowReady condition and the shadow component from the
+// readiness of the shadow model
+func (ss *InferenceServiceStatus) PropagateShadowStatus(status ComponentStatus) {
+	ss.setComponentStatus(ComponentShadow, status)
+	manager := conditionSet.Manage(ss)
+	switch status.Ready {
+	case corev1.ConditionTrue:
+		manager.MarkTrue(ShadowReady)
+	case corev1.ConditionFalse:
+		manager.MarkFalse(ShadowReady, status.Reason, "%s", status.Message)
+	default:
+		manager.MarkUnknown(ShadowReady, status.Reason, "%s", status.Message)
+	}
+}
+
+// ClearShadowStatus removes the readiness of the shadow model, once it is removed from the spec
+func (ss *InferenceServiceStatus) ClearShadowStatus() {
+	delete(ss.Components, ComponentShadow)
+	// only the Ready condition cannot be cleared
+	_ = conditionSet.Manage(ss).ClearCondition(ShadowReady)
+}
+
+// propagateCondition sets the condition of the given type from the matching backend
+// condition, which is Unknown until the backend reports it
+func (ss *InferenceServiceStatus) propagateCondition(t apis.ConditionType, condition *apis.Condition) {
+	manager := conditionSet.Manage(ss)
+	switch {
+	case condition == nil:
+		manager.MarkUnknown(t, "", "")
+	case condition.Status == corev1.ConditionTrue:
+		manager.MarkTrue(t)
+	case condition.Status == corev1.ConditionFalse:
+		manager.MarkFalse(t, condition.Reason, "%s", condition.Message)
+	default:
+		manager.MarkUnknown(t, condition.Reason, "%s", condition.Message)
+	}
+}
+
+// failureFromConditions returns the reason and message of the failing backend condition.
+// The top level Ready condition only summarizes the failures of the other conditions,
+// so it is used when none of them is failing.
+func failureFromConditions(conditions []apis.Condition) (reason, message string) {
+	var failed *apis.Condition
+	for i := range conditions {
+		if conditions[i].Status != corev1.ConditionFalse {
+			continue
+		}
+		if failed == nil || failed.Type == apis.ConditionReady {
+			failed = &conditions[i]
+		}
+	}
+	if failed == nil {
+		return ReasonBackendFailed, ""
+	}
+	reason = failed.Reason
+	if reason == "" {
+		reason = ReasonBackendFailed
+	}
+	return reason, failed.Message
+}
+
+// kfservingComponents maps the inference service components to the conditions
+// reporting their readiness in the kfserving inference service
+var kfservingComponents = map[ComponentType]apis.ConditionType{
+	ComponentPredictor:   kfservingv1.PredictorReady,
+	ComponentTransformer: kfservingv1.TransformerReady,
+	ComponentExplainer:   kfservingv1.ExplainerReady,
+}
+
+// componentStatusFromCondition returns the component readiness reported by a backend
+// condition, which is Unknown until the backend reports it
+func componentStatusFromCondition(condition *apis.Condition) ComponentStatus {
+	if condition == nil {
+		return ComponentStatus{Ready: corev1.ConditionUnknown}
+	}
+	status := ComponentStatus{Ready: condition.Status}
+	if condition.Status != corev1.ConditionTrue {
+		status.Reason, status.Message = condition.Reason, condition.Message
+	}
+	return status
+}
+
+// setComponentStatus sets the readiness of the given component
+func (ss *InferenceServiceStatus) setComponentStatus(component ComponentType, status ComponentStatus) {
+	if ss.Components == nil {
+		ss.Components = make(map[ComponentType]ComponentStatus)
+	}
+	ss.Components[component] = status
+}
+
+func (ss *InferenceServiceStatus) PropagateStatusFromKfserving(serviceStatus *kfservingv1.InferenceServiceStatus) {
+	ss.propagateCondition(PredictorReady, serviceStatus.GetCondition(kfservingv1.PredictorReady))
+	ss.propagateCondition(IngressReady, serviceStatus.GetCondition(kfservingv1.IngressReady))
+	ss.updateState()
+	ss.Reason, ss.Message = "", ""
+	switch ss.Status {
+	case StatusStateAvailable:
+		ss.URL = serviceStatus.URL
+	case StatusStateFailed:
+		// the conditions are not ordered, the top level Ready condition summarizes the others
+		ss.Reason, ss.Message = failureFromConditions(serviceStatus.Status.Conditions)
+	}
+
+	ss.Components = nil
+	for component, conditionType := range kfservingComponents {
+		condition := serviceStatus.GetCondition(conditionType)
+		componentStatus, deployed := serviceStatus.Components[kfservingv1.ComponentType(component)]
+		if condition == nil && !deployed {
+			continue
+		}
+		status := componentStatusFromCondition(condition)
+		status.URL = componentStatus.URL
+		ss.setComponentStatus(component, status)
+	}
+
+	// the latest predictor revision is the canary when the traffic is split
+	ss.Traffic = nil
+	if predictor, ok := serviceStatus.Components[kfservingv1.PredictorComponent]; ok {
+		for _, target := range predictor.Traffic {
+			name := TrafficTargetStable
+			if len(predictor.Traffic) > 1 && target.LatestRevision != nil && *target.LatestRevision {
+				name = TrafficTargetCanary
 			}
-		case "False":
-			ss.Status = StatusStateFailed
-		default:
-			ss.Status = StatusStateCreating
+			percent := int64(0)
+			if target.Percent != nil {
+				percent = *target.Percent
+			}
+			ss.Traffic = append(ss.Traffic, TrafficStatus{Name: name, Percent: percent})
 		}
 	}
 }
 
 func (ss *InferenceServiceStatus) PropagateStatusFromSeldon(serviceStatus *seldonv1.SeldonDeploymentStatus) {
+	// seldon deployments do not report conditions, so they are derived from the state
+	manager := conditionSet.Manage(ss)
 	switch serviceStatus.State {
 	case seldonv1.StatusStateAvailable:
-		if serviceStatus.Address != nil {
-			url, _ := apis.ParseURL(serviceStatus.Address.URL)
-			ss.URL = url
-		}
-		ss.Status = StatusStateAvailable
+		manager.MarkTrue(PredictorReady)
 	case seldonv1.StatusStateFailed:
-		ss.Status = StatusStateFailed
+		manager.MarkFalse(PredictorReady, ReasonDeploymentFailed, "%s", serviceStatus.Description)
 	default:
-		ss.Status = StatusStateCreating
+		manager.MarkUnknown(PredictorReady, "DeploymentCreating", "%s", serviceStatus.Description)
+	}
+	if serviceStatus.Address != nil && serviceStatus.Address.URL != "" {
+		manager.MarkTrue(IngressReady)
+	} else {
+		manager.MarkUnknown(IngressReady, "AddressNotReady", "The seldon deployment has no address yet")
+	}
+	ss.updateState()
+	ss.Reason, ss.Message = "", ""
+	switch ss.Status {
+	case StatusStateAvailable:
+		// the address includes the prediction path, which depends on the protocol
+		url, _ := apis.ParseURL(serviceStatus.Address.URL)
+		if url != nil {
+			url.Path = ""
+		}
+		ss.URL = url
+	case StatusStateFailed:
+		ss.Reason, ss.Message = ReasonDeploymentFailed, serviceStatus.Description
+	}
+
+	ss.Components = nil
+	ss.setComponentStatus(ComponentPredictor, componentStatusFromCondition(manager.GetCondition(PredictorReady)))
+
+	// explainers run in their own deployments
+	for _, deployment := range serviceStatus.DeploymentStatus {
+		if deployment.ExplainerFor != "" {
+			ss.setComponentStatus(ComponentExplainer, ComponentStatusFromSeldon(&deployment))
+		}
+	}
+}
+
+// ComponentStatusFromSeldon returns the readiness of a component running in a deployment
+// of a seldon deployment, which is ready once all its replicas are available
+func ComponentStatusFromSeldon(deployment *seldonv1.DeploymentStatus) ComponentStatus {
+	if deployment.Replicas == 0 || deployment.AvailableReplicas < deployment.Replicas {
+		return ComponentStatus{
+			Ready:   corev1.ConditionUnknown,
+			Reason:  "ReplicasNotAvailable",
+			Message: deployment.Description,
+		}
 	}
+	return ComponentStatus{Ready: corev1.ConditionTrue}
 }
@@ -0,0 +1,306 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+	"testing"
+
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	knservingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// wantStatus is the part of the status checked by the propagation tests
+type wantStatus struct {
+	state      StatusState
+	reason     string
+	message    string
+	url        string
+	components map[ComponentType]corev1.ConditionStatus
+	traffic    []TrafficStatus
+}
+
+// checkStatus reports the differences between the status and the expected one
+func checkStatus(t *testing.T, status *InferenceServiceStatus, want wantStatus) {
+	t.Helper()
+	if status.Status != want.state || status.Reason != want.reason || status.Message != want.message {
+		t.Errorf("status = %s (%s: %s), want %s (%s: %s)",
+			status.Status, status.Reason, status.Message, want.state, want.reason, want.message)
+	}
+	url := ""
+	if status.URL != nil {
+		url = status.URL.String()
+	}
+	if url != want.url {
+		t.Errorf("url = %q, want %q", url, want.url)
+	}
+	var components map[ComponentType]corev1.ConditionStatus
+	for component, componentStatus := range status.Components {
+		if components == nil {
+			components = make(map[ComponentType]corev1.ConditionStatus)
+		}
+		components[component] = componentStatus.Ready
+	}
+	if !reflect.DeepEqual(components, want.components) {
+		t.Errorf("components = %v, want %v", components, want.components)
+	}
+	if !reflect.DeepEqual(status.Traffic, want.traffic) {
+		t.Errorf("traffic = %v, want %v", status.Traffic, want.traffic)
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestPropagateStatusFromKfserving(t *testing.T) {
+	url, _ := apis.ParseURL("http://iris.default.example.com")
+	readyConditions := duckv1.Conditions{
+		{Type: apis.ConditionReady, Status: corev1.ConditionTrue},
+		{Type: kfservingv1.PredictorReady, Status: corev1.ConditionTrue},
+		{Type: kfservingv1.IngressReady, Status: corev1.ConditionTrue},
+	}
+	tests := []struct {
+		name   string
+		status kfservingv1.InferenceServiceStatus
+		want   wantStatus
+	}{
+		{
+			name: "ready",
+			status: kfservingv1.InferenceServiceStatus{
+				Status: duckv1.Status{Conditions: readyConditions},
+				URL:    url,
+				Components: map[kfservingv1.ComponentType]kfservingv1.ComponentStatusSpec{
+					kfservingv1.PredictorComponent: {Traffic: []knservingv1.TrafficTarget{
+						{Percent: int64Ptr(100), LatestRevision: boolPtr(true)},
+					}},
+				},
+			},
+			want: wantStatus{
+				state:      StatusStateAvailable,
+				url:        url.String(),
+				components: map[ComponentType]corev1.ConditionStatus{ComponentPredictor: corev1.ConditionTrue},
+				traffic:    []TrafficStatus{{Name: TrafficTargetStable, Percent: 100}},
+			},
+		},
+		{
+			name: "canary traffic",
+			status: kfservingv1.InferenceServiceStatus{
+				Status: duckv1.Status{Conditions: readyConditions},
+				URL:    url,
+				Components: map[kfservingv1.ComponentType]kfservingv1.ComponentStatusSpec{
+					kfservingv1.PredictorComponent: {Traffic: []knservingv1.TrafficTarget{
+						{Percent: int64Ptr(90), LatestRevision: boolPtr(false)},
+						{Percent: int64Ptr(10), LatestRevision: boolPtr(true)},
+					}},
+				},
+			},
+			want: wantStatus{
+				state:      StatusStateAvailable,
+				url:        url.String(),
+				components: map[ComponentType]corev1.ConditionStatus{ComponentPredictor: corev1.ConditionTrue},
+				traffic: []TrafficStatus{
+					{Name: TrafficTargetStable, Percent: 90},
+					{Name: TrafficTargetCanary, Percent: 10},
+				},
+			},
+		},
+		{
+			name: "failed predictor",
+			status: kfservingv1.InferenceServiceStatus{
+				Status: duckv1.Status{Conditions: duckv1.Conditions{
+					{Type: apis.ConditionReady, Status: corev1.ConditionFalse, Reason: "PredictorNotReady"},
+					{Type: kfservingv1.PredictorReady, Status: corev1.ConditionFalse, Reason: "RevisionFailed", Message: "image pull failed"},
+					{Type: kfservingv1.IngressReady, Status: corev1.ConditionTrue},
+				}},
+				URL: url,
+			},
+			want: wantStatus{
+				state:      StatusStateFailed,
+				reason:     "RevisionFailed",
+				message:    "image pull failed",
+				components: map[ComponentType]corev1.ConditionStatus{ComponentPredictor: corev1.ConditionFalse},
+			},
+		},
+		{
+			name: "creating",
+			status: kfservingv1.InferenceServiceStatus{
+				Status: duckv1.Status{Conditions: duckv1.Conditions{
+					{Type: kfservingv1.PredictorReady, Status: corev1.ConditionUnknown},
+				}},
+			},
+			want: wantStatus{
+				state:      StatusStateCreating,
+				components: map[ComponentType]corev1.ConditionStatus{ComponentPredictor: corev1.ConditionUnknown},
+			},
+		},
+		{
+			name: "not reported yet",
+			want: wantStatus{state: StatusStateCreating},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &InferenceServiceStatus{}
+			status.InitializeConditions()
+			status.MarkBackendResourceCreated()
+			status.PropagateStatusFromKfserving(&tt.status)
+			checkStatus(t, status, tt.want)
+		})
+	}
+}
+
+func TestPropagateStatusFromSeldon(t *testing.T) {
+	address := &seldonv1.SeldonAddressable{URL: "http://iris-default.default.svc.cluster.local:8000/api/v1.0/predictions"}
+	tests := []struct {
+		name   string
+		status seldonv1.SeldonDeploymentStatus
+		want   wantStatus
+	}{
+		{
+			name:   "available",
+			status: seldonv1.SeldonDeploymentStatus{State: seldonv1.StatusStateAvailable, Address: address},
+			want: wantStatus{
+				state:      StatusStateAvailable,
+				url:        "http://iris-default.default.svc.cluster.local:8000",
+				components: map[ComponentType]corev1.ConditionStatus{ComponentPredictor: corev1.ConditionTrue},
+			},
+		},
+		{
+			name:   "available without address",
+			status: seldonv1.SeldonDeploymentStatus{State: seldonv1.StatusStateAvailable},
+			want: wantStatus{
+				state:      StatusStateCreating,
+				components: map[ComponentType]corev1.ConditionStatus{ComponentPredictor: corev1.ConditionTrue},
+			},
+		},
+		{
+			name:   "creating",
+			status: seldonv1.SeldonDeploymentStatus{State: seldonv1.StatusStateCreating},
+			want: wantStatus{
+				state:      StatusStateCreating,
+				components: map[ComponentType]corev1.ConditionStatus{ComponentPredictor: corev1.ConditionUnknown},
+			},
+		},
+		{
+			name: "failed",
+			status: seldonv1.SeldonDeploymentStatus{
+				State:       seldonv1.StatusStateFailed,
+				Description: "Cannot find field: foo",
+				Address:     address,
+			},
+			want: wantStatus{
+				state:      StatusStateFailed,
+				reason:     ReasonDeploymentFailed,
+				message:    "Cannot find field: foo",
+				components: map[ComponentType]corev1.ConditionStatus{ComponentPredictor: corev1.ConditionFalse},
+			},
+		},
+		{
+			name: "explainer not available",
+			status: seldonv1.SeldonDeploymentStatus{
+				State:   seldonv1.StatusStateAvailable,
+				Address: address,
+				DeploymentStatus: map[string]seldonv1.DeploymentStatus{
+					"iris-default-0-iris": {Replicas: 1, AvailableReplicas: 1},
+					"iris-default-explainer": {
+						Replicas:     1,
+						ExplainerFor: "iris-default",
+					},
+				},
+			},
+			want: wantStatus{
+				state: StatusStateAvailable,
+				url:   "http://iris-default.default.svc.cluster.local:8000",
+				components: map[ComponentType]corev1.ConditionStatus{
+					ComponentPredictor: corev1.ConditionTrue,
+					ComponentExplainer: corev1.ConditionUnknown,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &InferenceServiceStatus{}
+			status.InitializeConditions()
+			status.MarkBackendResourceCreated()
+			status.PropagateStatusFromSeldon(&tt.status)
+			checkStatus(t, status, tt.want)
+		})
+	}
+}
+
+func TestFailureFromConditions(t *testing.T) {
+	tests := []struct {
+		name        string
+		conditions  []apis.Condition
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name: "no failed condition",
+			conditions: []apis.Condition{
+				{Type: apis.ConditionReady, Status: corev1.ConditionUnknown, Reason: "Pending"},
+			},
+			wantReason: ReasonBackendFailed,
+		},
+		{
+			name: "only the ready condition failed",
+			conditions: []apis.Condition{
+				{Type: apis.ConditionReady, Status: corev1.ConditionFalse, Reason: "IngressNotConfigured", Message: "no ingress"},
+			},
+			wantReason:  "IngressNotConfigured",
+			wantMessage: "no ingress",
+		},
+		{
+			name: "failed condition after the ready condition",
+			conditions: []apis.Condition{
+				{Type: apis.ConditionReady, Status: corev1.ConditionFalse, Reason: "PredictorNotReady"},
+				{Type: kfservingv1.PredictorReady, Status: corev1.ConditionFalse, Reason: "RevisionFailed", Message: "image pull failed"},
+			},
+			wantReason:  "RevisionFailed",
+			wantMessage: "image pull failed",
+		},
+		{
+			name: "failed condition without reason",
+			conditions: []apis.Condition{
+				{Type: kfservingv1.PredictorReady, Status: corev1.ConditionFalse, Message: "image pull failed"},
+			},
+			wantReason:  ReasonBackendFailed,
+			wantMessage: "image pull failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, message := failureFromConditions(tt.conditions)
+			if reason != tt.wantReason || message != tt.wantMessage {
+				t.Errorf("failureFromConditions() = %q, %q, want %q, %q", reason, message, tt.wantReason, tt.wantMessage)
+			}
+		})
+	}
+}
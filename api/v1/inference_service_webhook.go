@@ -0,0 +1,385 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var inferenceservicelog = logf.Log.WithName("inferenceservice-resource")
+
+// SupportedModelUriSchemes lists the URI schemes of the storages models can be loaded from
+var SupportedModelUriSchemes = []string{"s3", "gs", "pvc", "file", "http", "https"}
+
+// BackendValidator checks that the backend selected by the inference service is able
+// to deploy its spec
+// +kubebuilder:object:generate=false
+type BackendValidator func(isvc *InferenceService, config *OperatorConfig) error
+
+var (
+	// webhookConfig holds the defaults applied by the defaulting webhook
+	webhookConfig *OperatorConfig
+	// validateBackend checks the spec against the backend capabilities
+	validateBackend BackendValidator
+)
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks. The defaults
+// are taken from the operator configuration and the specs are checked by the backend validator.
+func (r *InferenceService) SetupWebhookWithManager(mgr ctrl.Manager, config *OperatorConfig, backendValidator BackendValidator) error {
+	webhookConfig = config
+	validateBackend = backendValidator
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-serving-fuseml-suse-v1-inferenceservice,mutating=true,failurePolicy=fail,groups=serving.fuseml.suse,resources=inferenceservices,verbs=create;update,versions=v1,name=minferenceservice.kb.io
+
+var _ webhook.Defaulter = &InferenceService{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *InferenceService) Default() {
+	inferenceservicelog.Info("default", "name", r.Name)
+
+	r.Spec.Default(webhookConfig)
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-serving-fuseml-suse-v1-inferenceservice,mutating=false,failurePolicy=fail,groups=serving.fuseml.suse,resources=inferenceservices,versions=v1,name=vinferenceservice.kb.io
+
+var _ webhook.Validator = &InferenceService{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *InferenceService) ValidateCreate() error {
+	inferenceservicelog.Info("validate create", "name", r.Name)
+
+	allErrs := r.validate()
+	if r.Spec.Canary != nil {
+		// the backends need a deployed stable model to split the traffic with the canary
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "canary"),
+			"cannot be set when creating the service, start the canary rollout once the stable model is deployed"))
+	}
+	return r.toInvalidError(allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *InferenceService) ValidateUpdate(old runtime.Object) error {
+	inferenceservicelog.Info("validate update", "name", r.Name)
+
+	if r.DeletionTimestamp != nil {
+		// the finalizer must always be removable from a service being deleted
+		return nil
+	}
+	oldService, ok := old.(*InferenceService)
+	if !ok {
+		return r.toInvalidError(r.validate())
+	}
+	if equality.Semantic.DeepEqual(oldService.Spec, r.Spec) {
+		// metadata updates, e.g. adding the finalizer, are not held back by specs
+		// accepted before the validation rules changed
+		return nil
+	}
+	allErrs := r.validate()
+	allErrs = append(allErrs, r.validateUpdate(oldService)...)
+	return r.toInvalidError(allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *InferenceService) ValidateDelete() error {
+	return nil
+}
+
+func (r *InferenceService) validate() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if r.Spec.Backend == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("backend"),
+			"must be set when the operator configuration has no default backend"))
+	}
+	if r.Spec.ModelUri == "" {
+		if r.Spec.RequiresModelUri() {
+			allErrs = append(allErrs, field.Required(specPath.Child("modelUri"),
+				"must be set unless the model variants or the model nodes of the graph set their own model"))
+		}
+	} else if err := validateModelUri(r.Spec.ModelUri); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("modelUri"), r.Spec.ModelUri, err.Error()))
+	}
+	if r.Spec.Canary != nil {
+		if err := validateModelUri(r.Spec.Canary.ModelUri); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("canary", "modelUri"), r.Spec.Canary.ModelUri, err.Error()))
+		}
+	}
+	if r.Spec.Shadow != nil {
+		if err := validateModelUri(r.Spec.Shadow.ModelUri); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("shadow", "modelUri"), r.Spec.Shadow.ModelUri, err.Error()))
+		}
+	}
+	if r.Spec.Explainer != nil && r.Spec.Explainer.StorageUri != "" {
+		if err := validateModelUri(r.Spec.Explainer.StorageUri); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("explainer", "storageUri"), r.Spec.Explainer.StorageUri, err.Error()))
+		}
+	}
+	if len(r.Spec.Graph) > 0 {
+		allErrs = append(allErrs, r.validateGraph(specPath)...)
+	}
+	if len(r.Spec.Variants) > 0 {
+		allErrs = append(allErrs, r.validateVariants(specPath)...)
+	}
+	if r.Spec.Container != nil && r.Spec.Container.Image == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("container", "image"), "must be set for a custom container"))
+	}
+	volumes := make(map[string]bool)
+	for _, volume := range r.Spec.Volumes {
+		volumes[volume.Name] = true
+	}
+	for i, mount := range r.Spec.VolumeMounts {
+		if !volumes[mount.Name] {
+			allErrs = append(allErrs, field.NotFound(specPath.Child("volumeMounts").Index(i).Child("name"), mount.Name))
+		}
+	}
+	if r.Spec.Storage != nil {
+		allErrs = append(allErrs, r.validateStorage(specPath.Child("storage"))...)
+	}
+	if r.Spec.Scaling != nil {
+		if err := r.Spec.Scaling.Validate(); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("scaling"), r.Spec.Scaling, err.Error()))
+		}
+	}
+	if len(allErrs) == 0 && validateBackend != nil {
+		// the backends only get to check specs that are otherwise valid
+		defaulted := r.DeepCopy()
+		defaulted.Spec.DefaultForBackend(webhookConfig, r.Spec.Backend)
+		if err := validateBackend(defaulted, webhookConfig); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("backend"), r.Spec.Backend, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateStorage checks that the models are stored in the storage of the provider
+func (r *InferenceService) validateStorage(path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	storage := r.Spec.Storage
+
+	if storage.Endpoint != "" && storage.Provider != StorageProviderS3 {
+		allErrs = append(allErrs, field.Forbidden(path.Child("endpoint"),
+			fmt.Sprintf("is not supported by the %s provider", storage.Provider)))
+	}
+	var modelUris []string
+	if r.Spec.ModelUri != "" {
+		modelUris = append(modelUris, r.Spec.ModelUri)
+	}
+	if r.Spec.Canary != nil {
+		modelUris = append(modelUris, r.Spec.Canary.ModelUri)
+	}
+	if r.Spec.Shadow != nil {
+		modelUris = append(modelUris, r.Spec.Shadow.ModelUri)
+	}
+	for _, variant := range r.Spec.Variants {
+		modelUris = append(modelUris, variant.ModelUri)
+	}
+	if r.Spec.Explainer != nil && r.Spec.Explainer.StorageUri != "" {
+		modelUris = append(modelUris, r.Spec.Explainer.StorageUri)
+	}
+	for _, node := range r.Spec.Graph {
+		if node.ModelUri != "" {
+			modelUris = append(modelUris, node.ModelUri)
+		}
+	}
+	schemes := storage.Provider.uriSchemes()
+	for _, modelUri := range modelUris {
+		uri, err := url.Parse(modelUri)
+		if err != nil {
+			// already reported by the model URI validation
+			continue
+		}
+		if !containsString(schemes, uri.Scheme) {
+			allErrs = append(allErrs, field.Invalid(path.Child("provider"), storage.Provider,
+				fmt.Sprintf("does not serve models from %s, expected one of: %s", modelUri, strings.Join(schemes, ", "))))
+		}
+	}
+	return allErrs
+}
+
+// validateVariants checks that the model variants have unique names and weights summing to 100
+func (r *InferenceService) validateVariants(specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	path := specPath.Child("variants")
+
+	if r.Spec.Canary != nil {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("canary"), "cannot be combined with model variants"))
+	}
+	names := make(map[string]bool)
+	var weights int32
+	for i, variant := range r.Spec.Variants {
+		if names[variant.Name] {
+			allErrs = append(allErrs, field.Duplicate(path.Index(i).Child("name"), variant.Name))
+		}
+		names[variant.Name] = true
+		if err := validateModelUri(variant.ModelUri); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("modelUri"), variant.ModelUri, err.Error()))
+		}
+		weights += variant.Weight
+	}
+	if weights != 100 {
+		allErrs = append(allErrs, field.Invalid(path, weights, "the weights of the variants must sum to 100"))
+	}
+	return allErrs
+}
+
+// validateGraph checks that the nodes of the inference graph form a tree rooted at the
+// first node and that every node can be served
+func (r *InferenceService) validateGraph(specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	path := specPath.Child("graph")
+
+	if r.Spec.Container != nil {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("container"), "cannot be combined with an inference graph"))
+	}
+	if len(r.Spec.Parameters) > 0 {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("parameters"), "cannot be combined with an inference graph"))
+	}
+
+	nodes := make(map[string]int)
+	for i, node := range r.Spec.Graph {
+		if _, ok := nodes[node.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(path.Index(i).Child("name"), node.Name))
+			continue
+		}
+		nodes[node.Name] = i
+		if node.GetType() != GraphNodeModel && node.Image == "" {
+			allErrs = append(allErrs, field.Required(path.Index(i).Child("image"),
+				fmt.Sprintf("must be set for a %s node", node.GetType())))
+		}
+		if node.ModelUri != "" {
+			if err := validateModelUri(node.ModelUri); err != nil {
+				allErrs = append(allErrs, field.Invalid(path.Index(i).Child("modelUri"), node.ModelUri, err.Error()))
+			}
+		}
+	}
+
+	// every node but the root has a single parent
+	parents := make(map[string]string)
+	for i, node := range r.Spec.Graph {
+		for j, child := range node.Children {
+			childPath := path.Index(i).Child("children").Index(j)
+			switch index, ok := nodes[child]; {
+			case !ok:
+				allErrs = append(allErrs, field.NotFound(childPath, child))
+			case index == 0:
+				allErrs = append(allErrs, field.Invalid(childPath, child, "the first node is the root of the graph"))
+			case parents[child] != "":
+				allErrs = append(allErrs, field.Invalid(childPath, child,
+					fmt.Sprintf("is already a child of %s", parents[child])))
+			default:
+				parents[child] = node.Name
+			}
+		}
+	}
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+
+	// with single parents, the nodes not reachable from the root are in cycles or in
+	// separate trees
+	reached := map[string]bool{r.Spec.Graph[0].Name: true}
+	pending := []string{r.Spec.Graph[0].Name}
+	for len(pending) > 0 {
+		node := r.Spec.Graph[nodes[pending[0]]]
+		pending = pending[1:]
+		for _, child := range node.Children {
+			if !reached[child] {
+				reached[child] = true
+				pending = append(pending, child)
+			}
+		}
+	}
+	for i, node := range r.Spec.Graph {
+		if !reached[node.Name] {
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("name"), node.Name,
+				"is not reachable from the first node of the graph"))
+		}
+	}
+	return allErrs
+}
+
+func (r *InferenceService) validateUpdate(old *InferenceService) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	// a canary rollout can only end with the canary action annotation or by removing
+	// the canary, otherwise the backends would lose track of the stable model
+	if old.Spec.Canary != nil && r.Spec.Canary != nil {
+		if r.Spec.ModelUri != old.Spec.ModelUri {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("modelUri"),
+				"cannot be changed while a canary rollout is in progress"))
+		}
+		if r.Spec.Backend != old.Spec.Backend {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("backend"),
+				"cannot be changed while a canary rollout is in progress"))
+		}
+		if r.Spec.Canary.ModelUri != old.Spec.Canary.ModelUri {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("canary", "modelUri"),
+				"cannot be changed while a canary rollout is in progress, promote or abort the canary first"))
+		}
+	}
+	if old.Spec.Canary == nil && r.Spec.Canary != nil && r.Spec.ModelUri != old.Spec.ModelUri {
+		// the deployed model is the stable one the canary is compared with
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("modelUri"),
+			"cannot be changed when starting a canary rollout"))
+	}
+	return allErrs
+}
+
+func (r *InferenceService) toInvalidError(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierr.NewInvalid(GroupVersion.WithKind("InferenceService").GroupKind(), r.Name, allErrs)
+}
+
+// validateModelUri checks that the model is stored in a supported storage
+func validateModelUri(modelUri string) error {
+	uri, err := url.Parse(modelUri)
+	if err != nil {
+		return err
+	}
+	if containsString(SupportedModelUriSchemes, uri.Scheme) {
+		return nil
+	}
+	return fmt.Errorf("unsupported URI scheme %q, expected one of: %s", uri.Scheme, strings.Join(SupportedModelUriSchemes, ", "))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
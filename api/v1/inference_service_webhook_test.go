@@ -0,0 +1,502 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	testModelUri  = "s3://models/sklearn/iris"
+	testCanaryUri = "s3://models/sklearn/iris-v2"
+)
+
+// newTestService returns a valid inference service, changed by the given function
+func newTestService(mutate func(spec *InferenceServiceSpec)) *InferenceService {
+	isvc := &InferenceService{
+		ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default"},
+		Spec: InferenceServiceSpec{
+			Backend:  "kfserving",
+			ModelUri: testModelUri,
+		},
+	}
+	if mutate != nil {
+		mutate(&isvc.Spec)
+	}
+	return isvc
+}
+
+// errorFields returns the paths of the fields reported by the errors
+func errorFields(errs field.ErrorList) []string {
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	return fields
+}
+
+// invalidFields returns the paths of the fields reported by an Invalid error
+func invalidFields(t *testing.T, err error) []string {
+	if err == nil {
+		return nil
+	}
+	statusErr, ok := err.(*apierr.StatusError)
+	if !ok || !apierr.IsInvalid(err) {
+		t.Fatalf("expected an Invalid error, got %v", err)
+	}
+	var fields []string
+	for _, cause := range statusErr.ErrStatus.Details.Causes {
+		fields = append(fields, cause.Field)
+	}
+	return fields
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(spec *InferenceServiceSpec)
+		want   []string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:   "missing backend",
+			mutate: func(spec *InferenceServiceSpec) { spec.Backend = "" },
+			want:   []string{"spec.backend"},
+		},
+		{
+			name:   "missing model uri",
+			mutate: func(spec *InferenceServiceSpec) { spec.ModelUri = "" },
+			want:   []string{"spec.modelUri"},
+		},
+		{
+			name:   "unsupported model uri scheme",
+			mutate: func(spec *InferenceServiceSpec) { spec.ModelUri = "ftp://models/iris" },
+			want:   []string{"spec.modelUri"},
+		},
+		{
+			name: "unsupported canary model uri scheme",
+			mutate: func(spec *InferenceServiceSpec) {
+				spec.Canary = &CanarySpec{ModelUri: "ftp://models/iris", TrafficPercent: 10}
+			},
+			want: []string{"spec.canary.modelUri"},
+		},
+		{
+			name:   "unsupported shadow model uri scheme",
+			mutate: func(spec *InferenceServiceSpec) { spec.Shadow = &ShadowSpec{ModelUri: "ftp://models/iris"} },
+			want:   []string{"spec.shadow.modelUri"},
+		},
+		{
+			name: "unsupported explainer storage uri scheme",
+			mutate: func(spec *InferenceServiceSpec) {
+				spec.Explainer = &ExplainerSpec{Type: ExplainerAnchorTabular, StorageUri: "ftp://models/explainer"}
+			},
+			want: []string{"spec.explainer.storageUri"},
+		},
+		{
+			name:   "custom container without image",
+			mutate: func(spec *InferenceServiceSpec) { spec.Container = &corev1.Container{Name: "server"} },
+			want:   []string{"spec.container.image"},
+		},
+		{
+			name: "volume mount without volume",
+			mutate: func(spec *InferenceServiceSpec) {
+				spec.VolumeMounts = []corev1.VolumeMount{{Name: "data", MountPath: "/data"}}
+			},
+			want: []string{"spec.volumeMounts[0].name"},
+		},
+		{
+			name: "volume mount with volume",
+			mutate: func(spec *InferenceServiceSpec) {
+				spec.Volumes = []corev1.Volume{{Name: "data"}}
+				spec.VolumeMounts = []corev1.VolumeMount{{Name: "data", MountPath: "/data"}}
+			},
+		},
+		{
+			name: "min replicas greater than max replicas",
+			mutate: func(spec *InferenceServiceSpec) {
+				spec.Scaling = &ScalingSpec{MinReplicas: int32Ptr(3), MaxReplicas: int32Ptr(1)}
+			},
+			want: []string{"spec.scaling"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newTestService(tt.mutate)
+			if got := errorFields(isvc.validate()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validate() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(spec *InferenceServiceSpec)
+		want   []string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "canary",
+			mutate: func(spec *InferenceServiceSpec) {
+				spec.Canary = &CanarySpec{ModelUri: testCanaryUri, TrafficPercent: 10}
+			},
+			want: []string{"spec.canary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newTestService(tt.mutate)
+			if got := invalidFields(t, isvc.ValidateCreate()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateCreate() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	withCanary := func(spec *InferenceServiceSpec) {
+		spec.Canary = &CanarySpec{ModelUri: testCanaryUri, TrafficPercent: 10}
+	}
+	tests := []struct {
+		name     string
+		old      func(spec *InferenceServiceSpec)
+		mutate   func(spec *InferenceServiceSpec)
+		deleting bool
+		want     []string
+	}{
+		{
+			name:   "valid spec change",
+			mutate: func(spec *InferenceServiceSpec) { spec.ModelUri = testCanaryUri },
+		},
+		{
+			name:   "invalid spec change",
+			mutate: func(spec *InferenceServiceSpec) { spec.ModelUri = "ftp://models/iris" },
+			want:   []string{"spec.modelUri"},
+		},
+		{
+			name: "unchanged invalid spec",
+			old:  func(spec *InferenceServiceSpec) { spec.Backend = "" },
+			mutate: func(spec *InferenceServiceSpec) {
+				spec.Backend = ""
+			},
+		},
+		{
+			name:     "invalid spec change while deleting",
+			mutate:   func(spec *InferenceServiceSpec) { spec.ModelUri = "ftp://models/iris" },
+			deleting: true,
+		},
+		{
+			name:   "start canary",
+			mutate: withCanary,
+		},
+		{
+			name: "start canary changing the model",
+			mutate: func(spec *InferenceServiceSpec) {
+				withCanary(spec)
+				spec.ModelUri = "s3://models/sklearn/iris-v3"
+			},
+			want: []string{"spec.modelUri"},
+		},
+		{
+			name: "change model during canary",
+			old:  withCanary,
+			mutate: func(spec *InferenceServiceSpec) {
+				withCanary(spec)
+				spec.ModelUri = "s3://models/sklearn/iris-v3"
+			},
+			want: []string{"spec.modelUri"},
+		},
+		{
+			name: "change canary model during canary",
+			old:  withCanary,
+			mutate: func(spec *InferenceServiceSpec) {
+				withCanary(spec)
+				spec.Canary.ModelUri = "s3://models/sklearn/iris-v3"
+			},
+			want: []string{"spec.canary.modelUri"},
+		},
+		{
+			name: "change backend during canary",
+			old:  withCanary,
+			mutate: func(spec *InferenceServiceSpec) {
+				withCanary(spec)
+				spec.Backend = "seldon"
+			},
+			want: []string{"spec.backend"},
+		},
+		{
+			name: "change canary traffic during canary",
+			old:  withCanary,
+			mutate: func(spec *InferenceServiceSpec) {
+				withCanary(spec)
+				spec.Canary.TrafficPercent = 50
+			},
+		},
+		{
+			name:   "promote canary",
+			old:    withCanary,
+			mutate: func(spec *InferenceServiceSpec) { spec.ModelUri = testCanaryUri },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := newTestService(tt.old)
+			isvc := newTestService(tt.mutate)
+			if tt.deleting {
+				now := metav1.Now()
+				isvc.DeletionTimestamp = &now
+			}
+			if got := invalidFields(t, isvc.ValidateUpdate(old)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateUpdate() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage StorageSpec
+		mutate  func(spec *InferenceServiceSpec)
+		want    []string
+	}{
+		{
+			name:    "s3 model",
+			storage: StorageSpec{Provider: StorageProviderS3, Endpoint: "http://minio:9000"},
+		},
+		{
+			name:    "pvc model",
+			storage: StorageSpec{Provider: StorageProviderPVC},
+			mutate:  func(spec *InferenceServiceSpec) { spec.ModelUri = "pvc://models/iris" },
+		},
+		{
+			name:    "endpoint of a provider other than s3",
+			storage: StorageSpec{Provider: StorageProviderGCS, Endpoint: "http://minio:9000"},
+			mutate:  func(spec *InferenceServiceSpec) { spec.ModelUri = "gs://models/iris" },
+			want:    []string{"spec.storage.endpoint"},
+		},
+		{
+			name:    "model outside of the provider storage",
+			storage: StorageSpec{Provider: StorageProviderGCS},
+			want:    []string{"spec.storage.provider"},
+		},
+		{
+			name:    "canary model outside of the provider storage",
+			storage: StorageSpec{Provider: StorageProviderS3},
+			mutate: func(spec *InferenceServiceSpec) {
+				spec.Canary = &CanarySpec{ModelUri: "gs://models/iris", TrafficPercent: 10}
+			},
+			want: []string{"spec.storage.provider"},
+		},
+		{
+			name:    "variant model outside of the provider storage",
+			storage: StorageSpec{Provider: StorageProviderS3},
+			mutate: func(spec *InferenceServiceSpec) {
+				spec.ModelUri = ""
+				spec.Variants = []VariantSpec{
+					{Name: "a", ModelUri: testModelUri, Weight: 50},
+					{Name: "b", ModelUri: "https://models.example.com/iris", Weight: 50},
+				}
+			},
+			want: []string{"spec.storage.provider"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newTestService(tt.mutate)
+			isvc.Spec.Storage = &tt.storage
+			got := errorFields(isvc.validateStorage(field.NewPath("spec", "storage")))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateStorage() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGraph(t *testing.T) {
+	tests := []struct {
+		name   string
+		graph  []GraphNode
+		mutate func(spec *InferenceServiceSpec)
+		want   []string
+	}{
+		{
+			name: "tree",
+			graph: []GraphNode{
+				{Name: "transformer", Type: GraphNodeTransformer, Image: "transformer:1.0", Children: []string{"model"}},
+				{Name: "model", ModelUri: testCanaryUri},
+			},
+		},
+		{
+			name: "duplicate node",
+			graph: []GraphNode{
+				{Name: "model", Children: []string{"other"}},
+				{Name: "other"},
+				{Name: "other"},
+			},
+			want: []string{"spec.graph[2].name"},
+		},
+		{
+			name:  "unknown child",
+			graph: []GraphNode{{Name: "model", Children: []string{"missing"}}},
+			want:  []string{"spec.graph[0].children[0]"},
+		},
+		{
+			name: "root as a child",
+			graph: []GraphNode{
+				{Name: "model", Children: []string{"other"}},
+				{Name: "other", Children: []string{"model"}},
+			},
+			want: []string{"spec.graph[1].children[0]"},
+		},
+		{
+			name: "node with two parents",
+			graph: []GraphNode{
+				{Name: "router", Type: GraphNodeRouter, Image: "router:1.0", Children: []string{"a", "b"}},
+				{Name: "a", Children: []string{"b"}},
+				{Name: "b"},
+			},
+			want: []string{"spec.graph[1].children[0]"},
+		},
+		{
+			name: "unreachable nodes",
+			graph: []GraphNode{
+				{Name: "model"},
+				{Name: "a", Children: []string{"b"}},
+				{Name: "b", Children: []string{"a"}},
+			},
+			want: []string{"spec.graph[1].name", "spec.graph[2].name"},
+		},
+		{
+			name:  "combiner without image",
+			graph: []GraphNode{{Name: "combiner", Type: GraphNodeCombiner}},
+			want:  []string{"spec.graph[0].image"},
+		},
+		{
+			name:  "unsupported node model uri scheme",
+			graph: []GraphNode{{Name: "model", ModelUri: "ftp://models/iris"}},
+			want:  []string{"spec.graph[0].modelUri"},
+		},
+		{
+			name:  "graph and custom container",
+			graph: []GraphNode{{Name: "model"}},
+			mutate: func(spec *InferenceServiceSpec) {
+				spec.Container = &corev1.Container{Name: "server", Image: "server:1.0"}
+			},
+			want: []string{"spec.container"},
+		},
+		{
+			name:   "graph and parameters",
+			graph:  []GraphNode{{Name: "model"}},
+			mutate: func(spec *InferenceServiceSpec) { spec.Parameters = map[string]string{"method": "predict"} },
+			want:   []string{"spec.parameters"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newTestService(tt.mutate)
+			isvc.Spec.Graph = tt.graph
+			if got := errorFields(isvc.validateGraph(field.NewPath("spec"))); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateGraph() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateVariants(t *testing.T) {
+	variants := func(weights ...int32) func(spec *InferenceServiceSpec) {
+		return func(spec *InferenceServiceSpec) {
+			spec.ModelUri = ""
+			for i, weight := range weights {
+				spec.Variants = append(spec.Variants, VariantSpec{
+					Name:     fmt.Sprintf("v%d", i),
+					ModelUri: testModelUri,
+					Weight:   weight,
+				})
+			}
+		}
+	}
+	tests := []struct {
+		name   string
+		mutate func(spec *InferenceServiceSpec)
+		want   []string
+	}{
+		{
+			name:   "variants without spec model uri",
+			mutate: variants(80, 20),
+		},
+		{
+			name: "duplicate names",
+			mutate: func(spec *InferenceServiceSpec) {
+				variants(50, 50)(spec)
+				spec.Variants[1].Name = spec.Variants[0].Name
+			},
+			want: []string{"spec.variants[1].name"},
+		},
+		{
+			name:   "weights not summing to 100",
+			mutate: variants(50, 20),
+			want:   []string{"spec.variants"},
+		},
+		{
+			name: "unsupported variant model uri scheme",
+			mutate: func(spec *InferenceServiceSpec) {
+				variants(50, 50)(spec)
+				spec.Variants[1].ModelUri = "ftp://models/iris"
+			},
+			want: []string{"spec.variants[1].modelUri"},
+		},
+		{
+			name: "variants and canary",
+			mutate: func(spec *InferenceServiceSpec) {
+				variants(50, 50)(spec)
+				spec.Canary = &CanarySpec{ModelUri: testCanaryUri, TrafficPercent: 10}
+			},
+			want: []string{"spec.canary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newTestService(tt.mutate)
+			if got := errorFields(isvc.validate()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validate() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// OperatorConfig holds the operator-wide settings applied to the inference services
+// that do not set them in their spec
+// +kubebuilder:object:generate=false
+type OperatorConfig struct {
+	// The backend used to serve the models
+	Backend string `json:"backend,omitempty"`
+
+	// The framework the models are assumed to be trained with
+	Framework Framework `json:"framework,omitempty"`
+
+	// The inference protocol served by the predictors, when unset the model servers
+	// use their default protocol
+	Protocol Protocol `json:"protocol,omitempty"`
+
+	// Compute resources of the predictor container
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// The scheduling profile of the pods serving the models
+	Scheduling *SchedulingSpec `json:"scheduling,omitempty"`
+
+	// The model server runtimes, by backend and framework
+	Runtimes map[string]map[Framework]RuntimeSpec `json:"runtimes,omitempty"`
+
+	// The images the model servers can be run from. An entry matches the image with the
+	// same name, any tag of the repository it names, or when it ends with * any image
+	// starting with the prefix. All images are allowed when the list is empty.
+	AllowedImages []string `json:"allowedImages,omitempty"`
+
+	// The image of the init containers downloading the models of the custom containers
+	// served by the seldon backend, which only downloads them itself for its prepackaged
+	// servers. It should match the storage initializer configured in seldon and must be
+	// allowed by the allowed images.
+	StorageInitializerImage string `json:"storageInitializerImage,omitempty"`
+
+	// The sink receiving the payloads captured for the services that enable logging
+	// without setting a URL
+	LoggingURL string `json:"loggingUrl,omitempty"`
+}
+
+// NewOperatorConfig returns the built-in operator configuration
+func NewOperatorConfig() *OperatorConfig {
+	return &OperatorConfig{
+		Framework: DefaultFramework,
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1000m"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+			},
+		},
+	}
+}
+
+// LoadOperatorConfig reads the operator configuration from a YAML or JSON file.
+// Settings missing from the file keep their built-in values.
+func LoadOperatorConfig(path string) (*OperatorConfig, error) {
+	config := NewOperatorConfig()
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fails to read operator config %q", path)
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, errors.Wrapf(err, "fails to parse operator config %q", path)
+	}
+	return config, nil
+}
+
+// Default fills in the fields left unset in the spec from the operator configuration
+func (s *InferenceServiceSpec) Default(config *OperatorConfig) {
+	if config == nil {
+		return
+	}
+	if s.Backend == "" {
+		s.Backend = config.Backend
+	}
+	if s.Framework == "" {
+		s.Framework = config.Framework
+	}
+	if s.Protocol == "" {
+		s.Protocol = config.Protocol
+	}
+	if s.Resources == nil {
+		s.Resources = config.Resources.DeepCopy()
+	}
+	if s.Logging != nil && s.Logging.URL == "" {
+		s.Logging.URL = config.LoggingURL
+	}
+}
+
+// DefaultForBackend fills in the fields left unset in the spec from the operator settings
+// applied when building the backend resource, i.e. the runtime of the backend and the
+// scheduling profile. Unlike Default, it is not applied by the defaulting webhook, so the
+// defaults follow backend switches and configuration changes.
+func (s *InferenceServiceSpec) DefaultForBackend(config *OperatorConfig, backend string) {
+	if config == nil {
+		return
+	}
+	if config.Scheduling != nil {
+		s.Scheduling = s.Scheduling.merge(config.Scheduling)
+	}
+	if s.Runtime == nil {
+		if runtime, ok := config.Runtimes[backend][s.GetFramework()]; ok {
+			s.Runtime = &runtime
+		}
+	}
+}
+
+// IsImageAllowed returns true when the image matches an entry of the allowed images
+func (c *OperatorConfig) IsImageAllowed(image string) bool {
+	if c == nil || len(c.AllowedImages) == 0 {
+		return true
+	}
+	for _, allowed := range c.AllowedImages {
+		switch {
+		case strings.HasSuffix(allowed, "*"):
+			if strings.HasPrefix(image, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+		case image == allowed, strings.HasPrefix(image, allowed+":"), strings.HasPrefix(image, allowed+"@"):
+			return true
+		}
+	}
+	return false
+}
+
+// merge returns the scheduling settings with the fields left unset taken from the defaults
+func (s *SchedulingSpec) merge(defaults *SchedulingSpec) *SchedulingSpec {
+	merged := defaults.DeepCopy()
+	if s == nil {
+		return merged
+	}
+	if s.NodeSelector != nil {
+		merged.NodeSelector = s.NodeSelector
+	}
+	if s.Tolerations != nil {
+		merged.Tolerations = s.Tolerations
+	}
+	if s.Affinity != nil {
+		merged.Affinity = s.Affinity
+	}
+	if s.PriorityClassName != "" {
+		merged.PriorityClassName = s.PriorityClassName
+	}
+	return merged
+}
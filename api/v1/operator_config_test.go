@@ -0,0 +1,251 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestIsImageAllowed(t *testing.T) {
+	allowed := []string{"seldonio/sklearnserver", "kfserving/*"}
+	tests := []struct {
+		name          string
+		allowedImages []string
+		image         string
+		want          bool
+	}{
+		{
+			name:  "empty list",
+			image: "example.com/server:1.0",
+			want:  true,
+		},
+		{
+			name:          "exact name",
+			allowedImages: allowed,
+			image:         "seldonio/sklearnserver",
+			want:          true,
+		},
+		{
+			name:          "tag of the repository",
+			allowedImages: allowed,
+			image:         "seldonio/sklearnserver:1.7.0",
+			want:          true,
+		},
+		{
+			name:          "digest of the repository",
+			allowedImages: allowed,
+			image:         "seldonio/sklearnserver@sha256:0123456789abcdef",
+			want:          true,
+		},
+		{
+			name:          "wildcard prefix",
+			allowedImages: allowed,
+			image:         "kfserving/sklearnserver:v0.5.1",
+			want:          true,
+		},
+		{
+			name:          "repository sharing the allowed prefix",
+			allowedImages: allowed,
+			image:         "seldonio/sklearnserver-dev:1.7.0",
+		},
+		{
+			name:          "image not allowed",
+			allowedImages: allowed,
+			image:         "example.com/server:1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &OperatorConfig{AllowedImages: tt.allowedImages}
+			if got := config.IsImageAllowed(tt.image); got != tt.want {
+				t.Errorf("IsImageAllowed(%q) = %v, want %v", tt.image, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("nil config", func(t *testing.T) {
+		var config *OperatorConfig
+		if !config.IsImageAllowed("example.com/server:1.0") {
+			t.Errorf("IsImageAllowed() = false, want true")
+		}
+	})
+}
+
+func TestDefault(t *testing.T) {
+	config := &OperatorConfig{
+		Backend:    "seldon",
+		Framework:  FrameworkXGBoost,
+		Protocol:   ProtocolV2,
+		Resources:  corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+		Scheduling: &SchedulingSpec{PriorityClassName: "serving"},
+		Runtimes: map[string]map[Framework]RuntimeSpec{
+			"seldon": {FrameworkXGBoost: {Image: "seldonio/xgboostserver:1.7.0"}},
+		},
+		LoggingURL: "http://sink",
+	}
+	customResources := &corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}}
+	tests := []struct {
+		name   string
+		config *OperatorConfig
+		spec   InferenceServiceSpec
+		want   InferenceServiceSpec
+	}{
+		{
+			name: "nil config",
+			spec: InferenceServiceSpec{ModelUri: testModelUri},
+			want: InferenceServiceSpec{ModelUri: testModelUri},
+		},
+		{
+			name:   "unset fields",
+			config: config,
+			spec:   InferenceServiceSpec{ModelUri: testModelUri, Logging: &LoggingSpec{}},
+			want: InferenceServiceSpec{
+				ModelUri:  testModelUri,
+				Backend:   "seldon",
+				Framework: FrameworkXGBoost,
+				Protocol:  ProtocolV2,
+				Resources: config.Resources.DeepCopy(),
+				Logging:   &LoggingSpec{URL: "http://sink"},
+			},
+		},
+		{
+			name:   "set fields",
+			config: config,
+			spec: InferenceServiceSpec{
+				ModelUri:  testModelUri,
+				Backend:   "kfserving",
+				Framework: FrameworkSKLearn,
+				Protocol:  ProtocolV1,
+				Resources: customResources,
+				Logging:   &LoggingSpec{URL: "http://other-sink"},
+			},
+			want: InferenceServiceSpec{
+				ModelUri:  testModelUri,
+				Backend:   "kfserving",
+				Framework: FrameworkSKLearn,
+				Protocol:  ProtocolV1,
+				Resources: customResources,
+				Logging:   &LoggingSpec{URL: "http://other-sink"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.spec
+			spec.Default(tt.config)
+			if !reflect.DeepEqual(spec, tt.want) {
+				t.Errorf("Default() = %+v, want %+v", spec, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultForBackend(t *testing.T) {
+	tolerations := []corev1.Toleration{{Key: "node-pool", Operator: corev1.TolerationOpEqual, Value: "serving"}}
+	config := &OperatorConfig{
+		Scheduling: &SchedulingSpec{
+			NodeSelector:      map[string]string{"node-pool": "serving"},
+			Tolerations:       tolerations,
+			PriorityClassName: "serving",
+		},
+		Runtimes: map[string]map[Framework]RuntimeSpec{
+			"kfserving": {FrameworkSKLearn: {Version: "0.2.1"}},
+			"seldon":    {FrameworkSKLearn: {Image: "seldonio/sklearnserver:1.7.0"}},
+		},
+	}
+	tests := []struct {
+		name    string
+		config  *OperatorConfig
+		backend string
+		spec    InferenceServiceSpec
+		want    InferenceServiceSpec
+	}{
+		{
+			name:    "nil config",
+			backend: "kfserving",
+			spec:    InferenceServiceSpec{Framework: FrameworkSKLearn},
+			want:    InferenceServiceSpec{Framework: FrameworkSKLearn},
+		},
+		{
+			name:    "runtime of the kfserving backend",
+			config:  config,
+			backend: "kfserving",
+			spec:    InferenceServiceSpec{Framework: FrameworkSKLearn},
+			want: InferenceServiceSpec{
+				Framework:  FrameworkSKLearn,
+				Runtime:    &RuntimeSpec{Version: "0.2.1"},
+				Scheduling: config.Scheduling.DeepCopy(),
+			},
+		},
+		{
+			name:    "runtime of the seldon backend",
+			config:  config,
+			backend: "seldon",
+			spec:    InferenceServiceSpec{Framework: FrameworkSKLearn},
+			want: InferenceServiceSpec{
+				Framework:  FrameworkSKLearn,
+				Runtime:    &RuntimeSpec{Image: "seldonio/sklearnserver:1.7.0"},
+				Scheduling: config.Scheduling.DeepCopy(),
+			},
+		},
+		{
+			name:    "framework without runtime",
+			config:  config,
+			backend: "kfserving",
+			spec:    InferenceServiceSpec{Framework: FrameworkXGBoost},
+			want: InferenceServiceSpec{
+				Framework:  FrameworkXGBoost,
+				Scheduling: config.Scheduling.DeepCopy(),
+			},
+		},
+		{
+			name:    "spec runtime and scheduling fields",
+			config:  config,
+			backend: "kfserving",
+			spec: InferenceServiceSpec{
+				Framework:  FrameworkSKLearn,
+				Runtime:    &RuntimeSpec{Version: "0.3.0"},
+				Scheduling: &SchedulingSpec{NodeSelector: map[string]string{"node-pool": "gpu"}},
+			},
+			want: InferenceServiceSpec{
+				Framework: FrameworkSKLearn,
+				Runtime:   &RuntimeSpec{Version: "0.3.0"},
+				Scheduling: &SchedulingSpec{
+					NodeSelector:      map[string]string{"node-pool": "gpu"},
+					Tolerations:       tolerations,
+					PriorityClassName: "serving",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.spec
+			spec.DefaultForBackend(tt.config, tt.backend)
+			if !reflect.DeepEqual(spec, tt.want) {
+				t.Errorf("DefaultForBackend() = %+v, want %+v", spec, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/record"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// recordedEvents returns the events emitted so far by the reconciler
+func recordedEvents(r *InferenceServiceReconciler) []string {
+	var events []string
+	recorder := r.Recorder.(*record.FakeRecorder)
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestCanaryAction(t *testing.T) {
+	const canaryModelUri = testModelUri + "-v2"
+	withCanary := func(isvc *servingv1.InferenceService) {
+		isvc.Spec.Canary = &servingv1.CanarySpec{ModelUri: canaryModelUri, TrafficPercent: 10}
+	}
+	tests := []struct {
+		name         string
+		mutate       func(isvc *servingv1.InferenceService)
+		action       string
+		wantModelUri string
+		wantCanary   bool
+		wantEvent    string
+	}{
+		{
+			name:         "promote",
+			mutate:       withCanary,
+			action:       servingv1.CanaryActionPromote,
+			wantModelUri: canaryModelUri,
+			wantEvent:    "Normal CanaryPromoted",
+		},
+		{
+			name:         "abort",
+			mutate:       withCanary,
+			action:       servingv1.CanaryActionAbort,
+			wantModelUri: testModelUri,
+			wantEvent:    "Normal CanaryAborted",
+		},
+		{
+			name:         "no canary rollout",
+			action:       servingv1.CanaryActionPromote,
+			wantModelUri: testModelUri,
+			wantEvent:    "Warning InvalidCanaryAction",
+		},
+		{
+			name:         "unknown action",
+			mutate:       withCanary,
+			action:       "rollback",
+			wantModelUri: testModelUri,
+			wantCanary:   true,
+			wantEvent:    "Warning InvalidCanaryAction",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newTestService("kfserving", tt.mutate)
+			isvc.Annotations = map[string]string{servingv1.CanaryActionAnnotationKey: tt.action}
+			r := newTestReconciler(t, isvc)
+
+			updated, err := reconcileService(t, r, isvc)
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if _, ok := updated.Annotations[servingv1.CanaryActionAnnotationKey]; ok {
+				t.Error("canary action annotation not removed")
+			}
+			if updated.Spec.ModelUri != tt.wantModelUri {
+				t.Errorf("model URI = %q, want %q", updated.Spec.ModelUri, tt.wantModelUri)
+			}
+			if gotCanary := updated.Spec.Canary != nil; gotCanary != tt.wantCanary {
+				t.Errorf("canary kept = %v, want %v", gotCanary, tt.wantCanary)
+			}
+			events := recordedEvents(r)
+			if len(events) != 1 || !strings.HasPrefix(events[0], tt.wantEvent+" ") {
+				t.Errorf("events = %q, want a single %q event", events, tt.wantEvent)
+			}
+		})
+	}
+}
@@ -19,30 +19,27 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierr "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlbuilder "sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
-
-	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
-	kfservingv1const "github.com/kubeflow/kfserving/pkg/constants"
-	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
-	seldonv1const "github.com/seldonio/seldon-core/operator/constants"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	servingv1 "fuseml.suse/api/v1"
-	"fuseml.suse/controllers/reconcilers/kfserving"
-	"fuseml.suse/controllers/reconcilers/seldon"
+	"fuseml.suse/controllers/reconcilers"
 	"fuseml.suse/controllers/utils"
 )
 
@@ -52,14 +49,12 @@ type InferenceServiceReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	// Config holds the operator-wide defaults for inference services
+	Config *servingv1.OperatorConfig
 }
 
 // +kubebuilder:rbac:groups=serving.fuseml.suse,resources=inferenceservices,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=serving.fuseml.suse,resources=inferenceservices/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=serving.kubeflow.org,resources=inferenceservices,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=serving.kubeflow.org,resources=inferenceservices/status,verbs=get
-// +kubebuilder:rbac:groups=machinelearning.seldon.io,resources=seldondeployments,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=machinelearning.seldon.io,resources=seldondeployments/status,verbs=get
 
 func (r *InferenceServiceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -105,6 +100,11 @@ func (r *InferenceServiceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, e
 	}
 	log.Info("Reconciling inference service", "apiVersion", infSvc.APIVersion, "isvc", infSvc.Name)
 
+	if action, ok := infSvc.ObjectMeta.Annotations[servingv1.CanaryActionAnnotationKey]; ok {
+		// the spec update triggers a new reconciliation
+		return ctrl.Result{}, r.applyCanaryAction(infSvc, action)
+	}
+
 	objectMeta := metav1.ObjectMeta{
 		Labels:      make(map[string]string),
 		Annotations: make(map[string]string),
@@ -118,95 +118,192 @@ func (r *InferenceServiceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, e
 		objectMeta.Labels[k] = v
 	}
 
-	if infSvc.Spec.Backend == "kfserving" {
-		timeoutSeconds := int64(60)
-		defaultProtocol := kfservingv1const.ProtocolV2
-		runtimeVersion := "0.2.1"
-		spec := kfservingv1.InferenceServiceSpec{
-			Predictor: kfservingv1.PredictorSpec{
-				ComponentExtensionSpec: kfservingv1.ComponentExtensionSpec{
-					TimeoutSeconds: &timeoutSeconds,
-				},
-				PodSpec: kfservingv1.PodSpec{
-					ServiceAccountName: infSvc.Spec.ServiceAccountName,
-				},
-				SKLearn: &kfservingv1.SKLearnSpec{
-					PredictorExtensionSpec: kfservingv1.PredictorExtensionSpec{
-						StorageURI:      &infSvc.Spec.ModelUri,
-						ProtocolVersion: &defaultProtocol,
-						RuntimeVersion:  &runtimeVersion,
-						Container: v1.Container{
-							Name: "kfserving-container",
-							Resources: v1.ResourceRequirements{
-								Limits: v1.ResourceList{
-									"cpu":    resource.MustParse("1000m"),
-									"memory": resource.MustParse("2Gi"),
-								},
-								Requests: v1.ResourceList{
-									"cpu":    resource.MustParse("100m"),
-									"memory": resource.MustParse("128Mi"),
-								},
-							},
-						},
-					},
-				},
-			},
+	infSvc.Status.InitializeConditions()
+	// the spec is processed once it is applied to the backend serving the model
+	processed := true
+	backendName := infSvc.Spec.Backend
+	if backendName == "" && r.Config != nil {
+		// the defaulting webhook may be disabled, so fall back on the operator default
+		backendName = r.Config.Backend
+	}
+	if backend, ok := reconcilers.Get(backendName); !ok {
+		// nothing can be deployed until the spec changes, so the request is not requeued
+		r.markUnknownBackend(infSvc, backendName)
+	} else {
+		active := infSvc.Status.Backend
+		if active == "" || active == backend.Name() {
+			if err := r.abortMigration(infSvc); err != nil {
+				return reconcile.Result{}, err
+			}
+			deployed, err := r.deployBackend(infSvc, &infSvc.Status, backend, objectMeta)
+			if err != nil {
+				// the status reports the failures retried with a backoff, e.g. missing storage credentials
+				if statusErr := r.updateStatus(infSvc); statusErr != nil {
+					return reconcile.Result{}, statusErr
+				}
+				return reconcile.Result{}, err
+			}
+			if deployed {
+				infSvc.Status.Backend = backend.Name()
+			}
+		} else {
+			applied, err := r.reconcileActiveBackend(infSvc, active, objectMeta)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if err := r.migrateBackend(infSvc, active, backend, objectMeta); err != nil {
+				return reconcile.Result{}, err
+			}
+			processed = applied || infSvc.Status.Backend == backend.Name()
 		}
+	}
 
-		kfsvcr := kfserving.NewKfservingReconciler(r.Client, r.Scheme, objectMeta, &spec)
+	if processed {
+		infSvc.Status.ObservedGeneration = infSvc.Generation
+	}
+	if err := r.updateStatus(infSvc); err != nil {
+		r.Recorder.Eventf(infSvc, v1.EventTypeWarning, "InternalError", err.Error())
+		return reconcile.Result{}, err
+	}
 
-		if err := controllerutil.SetControllerReference(infSvc, kfsvcr.Service, r.Scheme); err != nil {
-			return ctrl.Result{}, errors.Wrapf(err, "fails to set owner reference for predictor")
-		}
+	return ctrl.Result{}, nil
+}
 
-		status, err := kfsvcr.Reconcile()
-		if err != nil {
-			return reconcile.Result{}, errors.Wrapf(err, "fails to reconcile kfserving inference service")
-		}
+// applyCanaryAction promotes or aborts the canary rollout by updating the spec
+// and removes the annotation requesting it
+func (r *InferenceServiceReconciler) applyCanaryAction(infSvc *servingv1.InferenceService, action string) error {
+	delete(infSvc.ObjectMeta.Annotations, servingv1.CanaryActionAnnotationKey)
+	canary := infSvc.Spec.Canary
+	switch {
+	case canary == nil:
+		r.Recorder.Eventf(infSvc, v1.EventTypeWarning, "InvalidCanaryAction",
+			"Ignoring canary action %q, there is no canary rollout in progress", action)
+	case action == servingv1.CanaryActionPromote:
+		infSvc.Spec.ModelUri = canary.ModelUri
+		infSvc.Spec.Canary = nil
+		r.Recorder.Eventf(infSvc, v1.EventTypeNormal, "CanaryPromoted",
+			"Canary model %s promoted to stable", canary.ModelUri)
+	case action == servingv1.CanaryActionAbort:
+		infSvc.Spec.Canary = nil
+		r.Recorder.Eventf(infSvc, v1.EventTypeNormal, "CanaryAborted",
+			"Canary model %s aborted", canary.ModelUri)
+	default:
+		r.Recorder.Eventf(infSvc, v1.EventTypeWarning, "InvalidCanaryAction",
+			"Ignoring unknown canary action %q, expected %s or %s",
+			action, servingv1.CanaryActionPromote, servingv1.CanaryActionAbort)
+	}
 
-		infSvc.Status.PropagateStatusFromKfserving(status)
-	} else if infSvc.Spec.Backend == "seldon" {
-		replicas := int32(1)
-		impl := seldonv1.PredictiveUnitImplementation(seldonv1const.PrePackedServerSklearn)
-		spec := seldonv1.SeldonDeploymentSpec{
-			Name: infSvc.Name,
-			Predictors: []seldonv1.PredictorSpec{{
-				Name:     infSvc.Name,
-				Replicas: &replicas,
-				Graph: seldonv1.PredictiveUnit{
-					Implementation:   &impl,
-					ModelURI:         infSvc.Spec.ModelUri,
-					Name:             "classifier",
-					EnvSecretRefName: infSvc.Spec.ServiceAccountName,
-					Parameters: []seldonv1.Parameter{{
-						Name:  "method",
-						Type:  seldonv1.STRING,
-						Value: "predict",
-					}},
-				}},
-			},
-		}
+	if err := r.Update(context.Background(), infSvc); err != nil {
+		return errors.Wrapf(err, "fails to apply canary action %q", action)
+	}
+	return nil
+}
 
-		seldonr := seldon.NewSeldonReconciler(r.Client, r.Scheme, objectMeta, &spec)
+// deployBackend reconciles the backend resource of the inference service and propagates
+// its status into the given status. Specs that the backend cannot deploy mark the status
+// as failed instead of returning an error, in which case deployed is false.
+func (r *InferenceServiceReconciler) deployBackend(infSvc *servingv1.InferenceService, status *servingv1.InferenceServiceStatus,
+	backend reconcilers.Backend, objectMeta metav1.ObjectMeta) (deployed bool, err error) {
+	err = r.reconcileBackend(infSvc, status, backend, objectMeta)
+	if err == nil {
+		return true, nil
+	}
+	specErr, ok := reconcilers.AsSpecError(err)
+	if !ok {
+		return false, err
+	}
+	// the spec cannot be deployed as is, so there is no point in retrying
+	r.Log.Info("Inference service cannot be deployed", "inferenceservice", infSvc.Name,
+		"backend", backend.Name(), "reason", specErr.Reason, "message", specErr.Message)
+	r.markFailed(infSvc, status, specErr.Reason, specErr.Message)
+	return false, nil
+}
 
-		if err := controllerutil.SetControllerReference(infSvc, seldonr.Service, r.Scheme); err != nil {
-			return ctrl.Result{}, errors.Wrapf(err, "fails to set owner reference for predictor")
-		}
+// markUnknownBackend fails the inference service when the selected backend does not
+// match any registered backend
+func (r *InferenceServiceReconciler) markUnknownBackend(infSvc *servingv1.InferenceService, backendName string) {
+	names := strings.Join(reconcilers.Names(), ", ")
+	if backendName == "" {
+		r.markFailed(infSvc, &infSvc.Status, reconcilers.ReasonMissingBackend,
+			fmt.Sprintf("spec.backend is not set, expected one of: %s", names))
+		return
+	}
+	r.markFailed(infSvc, &infSvc.Status, reconcilers.ReasonUnknownBackend,
+		fmt.Sprintf("backend %q is not registered, expected one of: %s", backendName, names))
+}
 
-		status, err := seldonr.Reconcile()
-		if err != nil {
-			return reconcile.Result{}, errors.Wrapf(err, "fails to reconcile seldon inference service")
+// markFailed sets the given status as failed and emits a warning event, unless the
+// status already reports the same failure
+func (r *InferenceServiceReconciler) markFailed(infSvc *servingv1.InferenceService, status *servingv1.InferenceServiceStatus, reason, message string) {
+	previous := *status
+	status.MarkFailed(reason, message)
+	r.recordFailure(infSvc, &previous, status)
+}
+
+// recordFailure emits a warning event with the reason and message of a failed status,
+// unless the previous status already reported the same failure
+func (r *InferenceServiceReconciler) recordFailure(infSvc *servingv1.InferenceService, previous, status *servingv1.InferenceServiceStatus) {
+	if status.Status != servingv1.StatusStateFailed {
+		return
+	}
+	if previous.Status != servingv1.StatusStateFailed || previous.Reason != status.Reason || previous.Message != status.Message {
+		r.Recorder.Event(infSvc, v1.EventTypeWarning, status.Reason, status.Message)
+	}
+}
+
+// reconcileBackend builds the backend resource for the inference service, reconciles it
+// and propagates its status into the given status
+func (r *InferenceServiceReconciler) reconcileBackend(infSvc *servingv1.InferenceService, status *servingv1.InferenceServiceStatus,
+	backend reconcilers.Backend, objectMeta metav1.ObjectMeta) error {
+	// fill in the operator defaults for the fields left unset in the spec
+	defaulted := infSvc.DeepCopy()
+	defaulted.Spec.Default(r.Config)
+	defaulted.Spec.DefaultForBackend(r.Config, backend.Name())
+	if defaulted.Spec.ModelUri == "" && defaulted.Spec.RequiresModelUri() {
+		return reconcilers.NewSpecError(reconcilers.ReasonMissingModelUri,
+			"spec.modelUri must be set unless the model variants or the model nodes of the graph set their own model")
+	}
+
+	// the spec is checked by the backend before creating any resource for it
+	desired, err := backend.Build(defaulted, objectMeta, r.Config)
+	if err != nil {
+		return errors.Wrapf(err, "fails to build %s inference service", backend.Name())
+	}
+	if err := reconcilers.CheckImages(desired, r.Config); err != nil {
+		return err
+	}
+
+	if err := r.reconcileStorage(infSvc, defaulted, backend, objectMeta); err != nil {
+		if _, ok := reconcilers.AsSpecError(err); ok {
+			return err
 		}
+		// retried until e.g. the credentials secret is created, the failure is reported meanwhile
+		r.markFailed(infSvc, status, reconcilers.ReasonStorageFailed, err.Error())
+		return err
+	}
 
-		infSvc.Status.PropagateStatusFromSeldon(status)
+	if err := controllerutil.SetControllerReference(infSvc, desired, r.Scheme); err != nil {
+		return errors.Wrapf(err, "fails to set owner reference for predictor")
 	}
 
-	if err := r.updateStatus(infSvc); err != nil {
-		r.Recorder.Eventf(infSvc, v1.EventTypeWarning, "InternalError", err.Error())
-		return reconcile.Result{}, err
+	observed, err := backend.Reconcile(r.Client, r.Scheme, desired)
+	if err != nil {
+		// a resource rejected because of the overrides cannot be fixed by retrying
+		if cause := errors.Cause(err); defaulted.Spec.Overrides.ForBackend(backend.Name()) != nil &&
+			(apierr.IsInvalid(cause) || apierr.IsBadRequest(cause)) {
+			return reconcilers.NewSpecError(reconcilers.ReasonInvalidOverrides,
+				"the %s overrides produce an invalid resource: %v", backend.Name(), cause)
+		}
+		return errors.Wrapf(err, "fails to reconcile %s inference service", backend.Name())
 	}
+	status.MarkBackendResourceCreated()
 
-	return ctrl.Result{}, nil
+	previous := *status
+	if err := backend.PropagateStatus(r.Client, defaulted, status, observed); err != nil {
+		return errors.Wrapf(err, "fails to propagate %s inference service status", backend.Name())
+	}
+	r.recordFailure(infSvc, &previous, status)
+	return nil
 }
 
 func (r *InferenceServiceReconciler) updateStatus(desiredService *servingv1.InferenceService) error {
@@ -250,13 +347,42 @@ func (r *InferenceServiceReconciler) updateStatus(desiredService *servingv1.Infe
 }
 
 func isInferenceServiceAvailable(status servingv1.InferenceServiceStatus) bool {
-	return status.Status == servingv1.StatusStateAvailable
+	return status.IsReady()
 }
 
 func (r *InferenceServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &servingv1.InferenceService{},
+		credentialsSecretField, credentialsSecretName); err != nil {
+		return err
+	}
+
+	// the secrets are watched for the storage credentials as well as for the derived secrets
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&servingv1.InferenceService{}).
-		Owns(&kfservingv1.InferenceService{}).
-		Owns(&seldonv1.SeldonDeployment{}).
-		Complete(r)
+		Owns(&v1.ServiceAccount{}, ctrlbuilder.WithPredicates(hasStorageLabel)).
+		Watches(&source.Kind{Type: &v1.Secret{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.enqueueForSecret)})
+	for _, backend := range reconcilers.Backends() {
+		for _, ownedType := range backend.OwnedTypes() {
+			builder = builder.Owns(ownedType)
+		}
+		for _, watch := range backend.Watches() {
+			builder = builder.Watches(&source.Kind{Type: watch.Type},
+				&handler.EnqueueRequestsFromMapFunc{ToRequests: enqueueByLabel(watch.NameLabel)})
+		}
+	}
+	return builder.Complete(r)
+}
+
+// enqueueByLabel maps the events of a resource to the inference service named by its label
+func enqueueByLabel(label string) handler.ToRequestsFunc {
+	return func(obj handler.MapObject) []reconcile.Request {
+		name, ok := obj.Meta.GetLabels()[label]
+		if !ok {
+			return nil
+		}
+		return []reconcile.Request{{
+			NamespacedName: types.NamespacedName{Name: name, Namespace: obj.Meta.GetNamespace()},
+		}}
+	}
 }
@@ -0,0 +1,182 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+	_ "fuseml.suse/controllers/reconcilers/kfserving"
+	_ "fuseml.suse/controllers/reconcilers/seldon"
+)
+
+const testModelUri = "s3://models/sklearn/iris"
+
+// indexedClient filters the inference services listed by their credentials secret, which
+// the fake client cannot do without the field indexes of the manager cache
+type indexedClient struct {
+	client.Client
+}
+
+func (c *indexedClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	var secretName string
+	if listOpts.FieldSelector != nil {
+		secretName, _ = listOpts.FieldSelector.RequiresExactMatch(credentialsSecretField)
+		listOpts.FieldSelector = nil
+	}
+	if err := c.Client.List(ctx, list, listOpts); err != nil {
+		return err
+	}
+	services, ok := list.(*servingv1.InferenceServiceList)
+	if !ok || secretName == "" {
+		return nil
+	}
+	var items []servingv1.InferenceService
+	for _, service := range services.Items {
+		if names := credentialsSecretName(&service); len(names) == 1 && names[0] == secretName {
+			items = append(items, service)
+		}
+	}
+	services.Items = items
+	return nil
+}
+
+// newTestReconciler returns a reconciler backed by a fake client holding the given objects
+func newTestReconciler(t *testing.T, objs ...runtime.Object) *InferenceServiceReconciler {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{v1.AddToScheme, servingv1.AddToScheme} {
+		if err := addToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, backend := range reconcilers.Backends() {
+		if err := backend.AddToScheme(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &InferenceServiceReconciler{
+		Client:   &indexedClient{fake.NewFakeClientWithScheme(scheme, objs...)},
+		Log:      logr.Discard(),
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(100),
+		Config:   servingv1.NewOperatorConfig(),
+	}
+}
+
+// newTestService returns an inference service of the given backend, changed by the given function
+func newTestService(backend string, mutate func(isvc *servingv1.InferenceService)) *servingv1.InferenceService {
+	isvc := &servingv1.InferenceService{
+		ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default", Generation: 1},
+		Spec: servingv1.InferenceServiceSpec{
+			Backend:  backend,
+			ModelUri: testModelUri,
+		},
+	}
+	if mutate != nil {
+		mutate(isvc)
+	}
+	return isvc
+}
+
+// reconcileService reconciles the inference service and returns it as stored afterwards
+func reconcileService(t *testing.T, r *InferenceServiceReconciler, isvc *servingv1.InferenceService) (*servingv1.InferenceService, error) {
+	t.Helper()
+	namespacedName := types.NamespacedName{Name: isvc.Name, Namespace: isvc.Namespace}
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: namespacedName})
+	updated := &servingv1.InferenceService{}
+	if getErr := r.Get(context.TODO(), namespacedName, updated); getErr != nil {
+		t.Fatalf("fails to get inference service: %v", getErr)
+	}
+	return updated, err
+}
+
+func TestReconcileUnknownBackend(t *testing.T) {
+	tests := []struct {
+		name          string
+		backend       string
+		configBackend string
+		wantReason    string
+		wantBackend   string
+	}{
+		{
+			name:       "backend not set",
+			wantReason: reconcilers.ReasonMissingBackend,
+		},
+		{
+			name:          "backend set by the operator configuration",
+			configBackend: "kfserving",
+			wantBackend:   "kfserving",
+		},
+		{
+			name:       "backend not registered",
+			backend:    "triton",
+			wantReason: reconcilers.ReasonUnknownBackend,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newTestService(tt.backend, nil)
+			r := newTestReconciler(t, isvc)
+			r.Config.Backend = tt.configBackend
+
+			updated, err := reconcileService(t, r, isvc)
+			// nothing can be deployed until the spec changes, so there is no error to requeue with
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if updated.Status.Backend != tt.wantBackend {
+				t.Errorf("active backend = %q, want %q", updated.Status.Backend, tt.wantBackend)
+			}
+			if updated.Status.ObservedGeneration != updated.Generation {
+				t.Errorf("observed generation = %d, want %d", updated.Status.ObservedGeneration, updated.Generation)
+			}
+			if tt.wantReason == "" {
+				if updated.Status.Status == servingv1.StatusStateFailed {
+					t.Errorf("status failed with %q", updated.Status.Reason)
+				}
+				return
+			}
+			if updated.Status.Status != servingv1.StatusStateFailed || updated.Status.Reason != tt.wantReason {
+				t.Errorf("status %s (%q), want %s (%q)", updated.Status.Status, updated.Status.Reason,
+					servingv1.StatusStateFailed, tt.wantReason)
+			}
+
+			// the failure is only reported once
+			if _, err := reconcileService(t, r, updated); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if events := recordedEvents(r); len(events) != 1 {
+				t.Errorf("events = %q, want a single %s warning", events, tt.wantReason)
+			}
+		})
+	}
+}
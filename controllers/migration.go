@@ -0,0 +1,161 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// migrateBackend moves the inference service from the active backend to the one requested
+// in the spec. The active backend keeps serving the model until the new one is available,
+// then the status switches to the new backend and the previous resources are deleted.
+// Meanwhile the active backend is reconciled by reconcileActiveBackend.
+func (r *InferenceServiceReconciler) migrateBackend(infSvc *servingv1.InferenceService, active string,
+	backend reconcilers.Backend, objectMeta metav1.ObjectMeta) error {
+	migration := &servingv1.MigrationStatus{
+		From:  active,
+		To:    backend.Name(),
+		Phase: servingv1.MigrationPhaseProvisioning,
+	}
+	if previous := infSvc.Status.Migration; previous == nil || previous.To != migration.To || previous.From != migration.From {
+		// a migration to yet another backend is superseded by this one
+		if err := r.abortMigration(infSvc); err != nil {
+			return err
+		}
+		r.Recorder.Eventf(infSvc, v1.EventTypeNormal, "MigrationStarted",
+			"Migrating InferenceService [%v] from the %s to the %s backend", infSvc.Name, active, backend.Name())
+	}
+
+	// the new backend status is only published once the migration completes, a failure
+	// is kept in the migration status so it is not reported again
+	status := servingv1.InferenceServiceStatus{}
+	if previous := infSvc.Status.Migration; previous != nil && previous.To == migration.To && previous.Phase == servingv1.MigrationPhaseFailed {
+		status.MarkFailed(previous.Reason, previous.Message)
+	}
+	if _, err := r.deployBackend(infSvc, &status, backend, objectMeta); err != nil {
+		return err
+	}
+
+	switch status.Status {
+	case servingv1.StatusStateFailed:
+		migration.Phase = servingv1.MigrationPhaseFailed
+		migration.Reason = status.Reason
+		migration.Message = status.Message
+	case servingv1.StatusStateAvailable:
+		if previous, ok := reconcilers.Get(active); ok {
+			if err := r.deleteBackendResources(infSvc, previous); err != nil {
+				return err
+			}
+		}
+		status.Backend = backend.Name()
+		infSvc.Status = status
+		migration.Phase = servingv1.MigrationPhaseCompleted
+		r.Recorder.Eventf(infSvc, v1.EventTypeNormal, "MigrationCompleted",
+			"InferenceService [%v] migrated from the %s to the %s backend", infSvc.Name, active, backend.Name())
+	}
+	infSvc.Status.Migration = migration
+	return nil
+}
+
+// reconcileActiveBackend applies the spec to the backend serving the model while a migration
+// is in progress, and propagates its status. It returns false when the active backend cannot
+// deploy the spec, e.g. when the spec uses features of the new backend only, in which case
+// the active resource and the status are left as they are.
+func (r *InferenceServiceReconciler) reconcileActiveBackend(infSvc *servingv1.InferenceService, active string,
+	objectMeta metav1.ObjectMeta) (bool, error) {
+	backend, ok := reconcilers.Get(active)
+	if !ok {
+		return false, nil
+	}
+	if err := r.reconcileBackend(infSvc, &infSvc.Status, backend, objectMeta); err != nil {
+		specErr, ok := reconcilers.AsSpecError(err)
+		if !ok {
+			return false, err
+		}
+		r.Log.Info("Inference service spec not applied to the active backend during the migration",
+			"inferenceservice", infSvc.Name, "backend", active, "reason", specErr.Reason, "message", specErr.Message)
+		return false, nil
+	}
+	return true, nil
+}
+
+// abortMigration deletes the resources deployed by a migration that did not complete
+// before the spec was changed to a different backend
+func (r *InferenceServiceReconciler) abortMigration(infSvc *servingv1.InferenceService) error {
+	migration := infSvc.Status.Migration
+	if migration == nil || migration.Phase == servingv1.MigrationPhaseCompleted || migration.Phase == servingv1.MigrationPhaseAborted {
+		return nil
+	}
+	if target, ok := reconcilers.Get(migration.To); ok && migration.To != infSvc.Spec.Backend && migration.To != infSvc.Status.Backend {
+		if err := r.deleteBackendResources(infSvc, target); err != nil {
+			return err
+		}
+	}
+	migration.Phase = servingv1.MigrationPhaseAborted
+	migration.Reason, migration.Message = "", ""
+	r.Recorder.Eventf(infSvc, v1.EventTypeNormal, "MigrationAborted",
+		"Migration of InferenceService [%v] to the %s backend aborted", infSvc.Name, migration.To)
+	return nil
+}
+
+// deleteBackendResources deletes the resources created by the backend for the inference service
+func (r *InferenceServiceReconciler) deleteBackendResources(infSvc *servingv1.InferenceService, backend reconcilers.Backend) error {
+	for _, ownedType := range backend.OwnedTypes() {
+		if err := r.deleteControlledResource(infSvc, ownedType.DeepCopyObject(), infSvc.Name); err != nil {
+			return errors.Wrapf(err, "fails to delete %s backend resource", backend.Name())
+		}
+	}
+	return r.deleteStorageResources(infSvc, backend)
+}
+
+// deleteControlledResource deletes the named resource, unless it does not exist or was not
+// created for the inference service
+func (r *InferenceServiceReconciler) deleteControlledResource(infSvc *servingv1.InferenceService, obj runtime.Object, name string) error {
+	namespacedName := types.NamespacedName{Name: name, Namespace: infSvc.Namespace}
+	if err := r.Get(context.TODO(), namespacedName, obj); err != nil {
+		if apierr.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// never delete resources that were not created for this inference service
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(accessor, infSvc) {
+		return nil
+	}
+
+	r.Log.Info("Deleting resource", "inferenceservice", infSvc.Name, "name", name)
+	if err := r.Delete(context.TODO(), obj); err != nil && !apierr.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
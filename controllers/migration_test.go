@@ -0,0 +1,205 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// startMigration deploys an inference service with the kfserving backend, then changes
+// the spec to the seldon backend with the given function and reconciles it again
+func startMigration(t *testing.T, mutate func(isvc *servingv1.InferenceService)) (*InferenceServiceReconciler, *servingv1.InferenceService) {
+	t.Helper()
+	isvc := newTestService("kfserving", nil)
+	r := newTestReconciler(t, isvc)
+	deployed, err := reconcileService(t, r, isvc)
+	if err != nil {
+		t.Fatalf("fails to deploy the kfserving backend: %v", err)
+	}
+	if deployed.Status.Backend != "kfserving" {
+		t.Fatalf("active backend = %q, want kfserving", deployed.Status.Backend)
+	}
+
+	deployed.Spec.Backend = "seldon"
+	if mutate != nil {
+		mutate(deployed)
+	}
+	migrating, err := updateAndReconcile(t, r, deployed)
+	if err != nil {
+		t.Fatalf("fails to start the migration: %v", err)
+	}
+	return r, migrating
+}
+
+// updateAndReconcile stores the changed spec of the inference service as a new generation and reconciles it
+func updateAndReconcile(t *testing.T, r *InferenceServiceReconciler, isvc *servingv1.InferenceService) (*servingv1.InferenceService, error) {
+	t.Helper()
+	isvc.Generation++
+	if err := r.Update(context.TODO(), isvc); err != nil {
+		t.Fatalf("fails to update inference service: %v", err)
+	}
+	return reconcileService(t, r, isvc)
+}
+
+// backendResource returns the resource deployed for the inference service, or nil when it does not exist
+func backendResource(t *testing.T, r *InferenceServiceReconciler, obj runtime.Object) runtime.Object {
+	t.Helper()
+	if err := r.Get(context.TODO(), types.NamespacedName{Name: "iris", Namespace: "default"}, obj); err != nil {
+		if apierr.IsNotFound(err) {
+			return nil
+		}
+		t.Fatalf("fails to get backend resource: %v", err)
+	}
+	return obj
+}
+
+func checkMigration(t *testing.T, isvc *servingv1.InferenceService, phase servingv1.MigrationPhase, reason string) {
+	t.Helper()
+	migration := isvc.Status.Migration
+	if migration == nil {
+		t.Fatalf("no migration status, want phase %s", phase)
+	}
+	if migration.From != "kfserving" || migration.To != "seldon" {
+		t.Errorf("migration from %q to %q, want from kfserving to seldon", migration.From, migration.To)
+	}
+	if migration.Phase != phase || migration.Reason != reason {
+		t.Errorf("migration phase %s (%q), want %s (%q)", migration.Phase, migration.Reason, phase, reason)
+	}
+}
+
+func TestMigrationStart(t *testing.T) {
+	timeoutSeconds := int64(30)
+	r, isvc := startMigration(t, func(isvc *servingv1.InferenceService) {
+		isvc.Spec.TimeoutSeconds = &timeoutSeconds
+	})
+
+	checkMigration(t, isvc, servingv1.MigrationPhaseProvisioning, "")
+	if isvc.Status.Backend != "kfserving" {
+		t.Errorf("active backend = %q, want kfserving until the migration completes", isvc.Status.Backend)
+	}
+	if isvc.Status.ObservedGeneration != isvc.Generation {
+		t.Errorf("observed generation = %d, want %d", isvc.Status.ObservedGeneration, isvc.Generation)
+	}
+	if backendResource(t, r, &seldonv1.SeldonDeployment{}) == nil {
+		t.Error("seldon deployment not created")
+	}
+	// the active backend keeps receiving the spec changes during the migration
+	service, ok := backendResource(t, r, &kfservingv1.InferenceService{}).(*kfservingv1.InferenceService)
+	if !ok {
+		t.Fatal("kfserving inference service deleted before the migration completes")
+	}
+	if got := service.Spec.Predictor.TimeoutSeconds; got == nil || *got != timeoutSeconds {
+		t.Errorf("kfserving predictor timeout not updated to %d", timeoutSeconds)
+	}
+}
+
+func TestMigrationSpecUnsupportedByActiveBackend(t *testing.T) {
+	r, isvc := startMigration(t, func(isvc *servingv1.InferenceService) {
+		isvc.Spec.Graph = []servingv1.GraphNode{{Name: "model", Image: "server:1.0"}}
+	})
+
+	checkMigration(t, isvc, servingv1.MigrationPhaseProvisioning, "")
+	if isvc.Status.Status == servingv1.StatusStateFailed {
+		t.Errorf("status failed with %q, want the status of the active backend", isvc.Status.Reason)
+	}
+	// the spec is not applied to the backend serving the model until the migration completes
+	if isvc.Status.ObservedGeneration == isvc.Generation {
+		t.Errorf("observed generation = %d, want the previous generation", isvc.Status.ObservedGeneration)
+	}
+	if backendResource(t, r, &kfservingv1.InferenceService{}) == nil {
+		t.Error("kfserving inference service deleted before the migration completes")
+	}
+}
+
+func TestMigrationFailure(t *testing.T) {
+	r, isvc := startMigration(t, func(isvc *servingv1.InferenceService) {
+		isvc.Spec.Framework = servingv1.FrameworkLightGBM
+	})
+
+	checkMigration(t, isvc, servingv1.MigrationPhaseFailed, reconcilers.ReasonUnsupportedFramework)
+	if isvc.Status.Backend != "kfserving" || isvc.Status.Status == servingv1.StatusStateFailed {
+		t.Errorf("status %s of the %q backend, want the status of the kfserving backend", isvc.Status.Status, isvc.Status.Backend)
+	}
+	if isvc.Status.ObservedGeneration != isvc.Generation {
+		t.Errorf("observed generation = %d, want %d", isvc.Status.ObservedGeneration, isvc.Generation)
+	}
+	if backendResource(t, r, &kfservingv1.InferenceService{}) == nil {
+		t.Error("kfserving inference service deleted after the migration failed")
+	}
+
+	// the failure is kept until the spec changes
+	isvc, err := reconcileService(t, r, isvc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMigration(t, isvc, servingv1.MigrationPhaseFailed, reconcilers.ReasonUnsupportedFramework)
+}
+
+func TestMigrationRevert(t *testing.T) {
+	r, isvc := startMigration(t, nil)
+
+	isvc.Spec.Backend = "kfserving"
+	isvc, err := updateAndReconcile(t, r, isvc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMigration(t, isvc, servingv1.MigrationPhaseAborted, "")
+	if isvc.Status.Backend != "kfserving" {
+		t.Errorf("active backend = %q, want kfserving", isvc.Status.Backend)
+	}
+	if backendResource(t, r, &seldonv1.SeldonDeployment{}) != nil {
+		t.Error("seldon deployment not deleted after the migration was aborted")
+	}
+	if backendResource(t, r, &kfservingv1.InferenceService{}) == nil {
+		t.Error("kfserving inference service deleted after the migration was aborted")
+	}
+}
+
+func TestMigrationComplete(t *testing.T) {
+	r, isvc := startMigration(t, nil)
+
+	deployment := backendResource(t, r, &seldonv1.SeldonDeployment{}).(*seldonv1.SeldonDeployment)
+	deployment.Status = seldonv1.SeldonDeploymentStatus{
+		State:   seldonv1.StatusStateAvailable,
+		Address: &seldonv1.SeldonAddressable{URL: "http://iris-default.seldon.svc.cluster.local:8000/api/v1.0/predictions"},
+	}
+	if err := r.Update(context.TODO(), deployment); err != nil {
+		t.Fatal(err)
+	}
+
+	isvc, err := reconcileService(t, r, isvc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMigration(t, isvc, servingv1.MigrationPhaseCompleted, "")
+	if isvc.Status.Backend != "seldon" || isvc.Status.Status != servingv1.StatusStateAvailable {
+		t.Errorf("status %s of the %q backend, want the available seldon backend", isvc.Status.Status, isvc.Status.Backend)
+	}
+	if backendResource(t, r, &kfservingv1.InferenceService{}) != nil {
+		t.Error("kfserving inference service not deleted after the migration completed")
+	}
+}
@@ -0,0 +1,61 @@
+package reconcilers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// Object is a kubernetes resource managed by a serving backend
+type Object interface {
+	metav1.Object
+	runtime.Object
+}
+
+// Watch is a resource type watched on behalf of a backend, usually created by the backend
+// controller itself, whose resources are labelled with the name of the inference service
+type Watch struct {
+	// Type is the watched resource type
+	Type runtime.Object
+	// NameLabel is the label holding the name of the inference service in the same namespace
+	NameLabel string
+}
+
+// Backend is implemented by every serving backend able to deploy an InferenceService.
+// Backends register themselves with Register, usually from an init function, and are
+// selected by the value of spec.backend.
+type Backend interface {
+	// Name returns the spec.backend value handled by this backend
+	Name() string
+
+	// AddToScheme registers the API types of the backend resources with the given scheme
+	AddToScheme(scheme *runtime.Scheme) error
+
+	// OwnedTypes returns the resource types created by the backend, which the
+	// controller watches to be notified about status changes
+	OwnedTypes() []runtime.Object
+
+	// Watches returns the resource types which are not owned by the backend resource but
+	// whose status is propagated into the inference service status
+	Watches() []Watch
+
+	// Build returns the desired backend resource for the inference service. The operator
+	// configuration holds the settings of the backend that are not part of the spec.
+	Build(isvc *servingv1.InferenceService, componentMeta metav1.ObjectMeta, config *servingv1.OperatorConfig) (Object, error)
+
+	// BuildStorage returns the resources passing the storage credentials to the backend in
+	// its native form, e.g. secrets or service accounts, which the controller creates before
+	// the backend resource. The credentials are nil when spec.storage does not reference a secret.
+	BuildStorage(isvc *servingv1.InferenceService, componentMeta metav1.ObjectMeta, credentials *corev1.Secret) ([]Object, error)
+
+	// Reconcile creates or updates the desired backend resource and returns the observed one
+	Reconcile(client client.Client, scheme *runtime.Scheme, desired Object) (Object, error)
+
+	// PropagateStatus maps the status of the observed backend resource, built from the
+	// given inference service, into the inference service status. The client can be used
+	// to read the status of resources created by the backend itself.
+	PropagateStatus(client client.Client, isvc *servingv1.InferenceService, status *servingv1.InferenceServiceStatus, observed Object) error
+}
@@ -0,0 +1,28 @@
+package reconcilers
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// ApplyEnvironment adds the environment variables and the volume mounts set in the
+// spec to the container serving the model
+func ApplyEnvironment(spec *servingv1.InferenceServiceSpec, container *v1.Container) {
+	container.Env = append(container.Env, spec.Env...)
+	container.EnvFrom = append(container.EnvFrom, spec.EnvFrom...)
+	container.VolumeMounts = append(container.VolumeMounts, spec.VolumeMounts...)
+}
+
+// ParameterKeys returns the keys of the parameters set in the spec, sorted so that
+// the backend resources do not change between reconciliations
+func ParameterKeys(spec *servingv1.InferenceServiceSpec) []string {
+	keys := make([]string, 0, len(spec.Parameters))
+	for key := range spec.Parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,51 @@
+package reconcilers
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Reasons reported by backends when an inference service spec cannot be deployed
+const (
+	ReasonUnknownBackend       = "UnknownBackend"
+	ReasonMissingBackend       = "MissingBackend"
+	ReasonUnsupportedFramework = "UnsupportedFramework"
+	ReasonInvalidScaling       = "InvalidScaling"
+	ReasonUnsupportedScaling   = "UnsupportedScaling"
+	ReasonUnsupportedProtocol  = "UnsupportedProtocol"
+	ReasonUnsupportedRuntime   = "UnsupportedRuntime"
+	ReasonImageNotAllowed      = "ImageNotAllowed"
+	ReasonUnsupportedParameter = "UnsupportedParameter"
+	ReasonInvalidOverrides     = "InvalidOverrides"
+	ReasonUnsupportedExplainer = "UnsupportedExplainer"
+	ReasonUnsupportedGraph     = "UnsupportedGraph"
+	ReasonUnsupportedVariants  = "UnsupportedVariants"
+	ReasonInvalidVariants      = "InvalidVariants"
+	ReasonInvalidCanary        = "InvalidCanary"
+	ReasonMissingModelUri      = "MissingModelUri"
+)
+
+// SpecError is returned by a backend when the inference service spec cannot be
+// deployed. Retrying does not help, so the controller marks the service as failed.
+type SpecError struct {
+	Reason  string
+	Message string
+}
+
+func (e *SpecError) Error() string {
+	return e.Message
+}
+
+// NewSpecError returns a SpecError with the given reason and formatted message
+func NewSpecError(reason string, format string, args ...interface{}) error {
+	return &SpecError{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// AsSpecError returns the SpecError wrapped by err, if any
+func AsSpecError(err error) (*SpecError, bool) {
+	if specErr, ok := errors.Cause(err).(*SpecError); ok {
+		return specErr, true
+	}
+	return nil, false
+}
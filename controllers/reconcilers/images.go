@@ -0,0 +1,66 @@
+package reconcilers
+
+import (
+	"reflect"
+
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+var containerType = reflect.TypeOf(v1.Container{})
+
+// CheckImages returns a SpecError when a container of the backend resource runs an image
+// that is not allowed by the operator configuration. The resource is checked once built,
+// so that the images set by the overrides are checked as well.
+func CheckImages(object Object, config *servingv1.OperatorConfig) error {
+	for _, image := range containerImages(reflect.ValueOf(object)) {
+		if !config.IsImageAllowed(image) {
+			return NewSpecError(ReasonImageNotAllowed,
+				"image %q is not in the allowed images of the operator configuration", image)
+		}
+	}
+	return nil
+}
+
+// containerImages returns the images of the containers found in the value. The containers
+// without image run the image selected by the backend from its own configuration.
+func containerImages(value reflect.Value) []string {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return containerImages(value.Elem())
+	case reflect.Slice, reflect.Array:
+		var images []string
+		for i := 0; i < value.Len(); i++ {
+			images = append(images, containerImages(value.Index(i))...)
+		}
+		return images
+	case reflect.Map:
+		var images []string
+		iter := value.MapRange()
+		for iter.Next() {
+			images = append(images, containerImages(iter.Value())...)
+		}
+		return images
+	case reflect.Struct:
+		if value.Type() == containerType {
+			if image := value.FieldByName("Image").String(); image != "" {
+				return []string{image}
+			}
+			return nil
+		}
+		var images []string
+		for i := 0; i < value.NumField(); i++ {
+			// the unexported fields hold no containers, e.g. those of the quantities
+			if value.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			images = append(images, containerImages(value.Field(i))...)
+		}
+		return images
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package reconcilers
+
+import (
+	"testing"
+
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// newSeldonDeployment returns a seldon deployment whose pods run the given images
+func newSeldonDeployment(images ...string) *seldonv1.SeldonDeployment {
+	var containers []v1.Container
+	for _, image := range images {
+		containers = append(containers, v1.Container{
+			Name:  "server",
+			Image: image,
+			Resources: v1.ResourceRequirements{
+				Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			},
+		})
+	}
+	return &seldonv1.SeldonDeployment{
+		Spec: seldonv1.SeldonDeploymentSpec{
+			Predictors: []seldonv1.PredictorSpec{{
+				Name:           "iris",
+				ComponentSpecs: []*seldonv1.SeldonPodSpec{{Spec: v1.PodSpec{Containers: containers}}},
+			}},
+		},
+	}
+}
+
+func TestCheckImages(t *testing.T) {
+	config := &servingv1.OperatorConfig{AllowedImages: []string{"seldonio/*"}}
+	tests := []struct {
+		name       string
+		object     Object
+		config     *servingv1.OperatorConfig
+		wantReason string
+	}{
+		{
+			name:   "allowed images",
+			object: newSeldonDeployment("seldonio/sklearnserver:1.7.0", "seldonio/xgboostserver:1.7.0"),
+			config: config,
+		},
+		{
+			name:   "containers without image",
+			object: newSeldonDeployment(""),
+			config: config,
+		},
+		{
+			name:   "no allowed images",
+			object: newSeldonDeployment("example.com/server:1.0"),
+		},
+		{
+			name:       "container image not allowed",
+			object:     newSeldonDeployment("seldonio/sklearnserver:1.7.0", "example.com/server:1.0"),
+			config:     config,
+			wantReason: ReasonImageNotAllowed,
+		},
+		{
+			name: "init container image not allowed",
+			object: &v1.Pod{Spec: v1.PodSpec{
+				InitContainers: []v1.Container{{Name: "initializer", Image: "example.com/initializer:1.0"}},
+				Containers:     []v1.Container{{Name: "server", Image: "seldonio/sklearnserver:1.7.0"}},
+			}},
+			config:     config,
+			wantReason: ReasonImageNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckImages(tt.object, tt.config)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("CheckImages() error = %v", err)
+				}
+				return
+			}
+			if specErr, ok := AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+				t.Fatalf("CheckImages() error = %v, want reason %s", err, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package kfserving
+
+import (
+	"fmt"
+
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	kfservingv1const "github.com/kubeflow/kfserving/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// BackendName is the spec.backend value that selects the KFServing backend
+const BackendName = "kfserving"
+
+// +kubebuilder:rbac:groups=serving.kubeflow.org,resources=inferenceservices,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=serving.kubeflow.org,resources=inferenceservices/status,verbs=get
+// +kubebuilder:rbac:groups=autoscaling.internal.knative.dev,resources=podautoscalers,verbs=get;list;watch
+
+// Backend deploys inference services as KFServing InferenceServices
+type Backend struct{}
+
+var _ reconcilers.Backend = &Backend{}
+
+func init() {
+	reconcilers.Register(&Backend{})
+}
+
+func (b *Backend) Name() string {
+	return BackendName
+}
+
+func (b *Backend) AddToScheme(scheme *runtime.Scheme) error {
+	if err := kfservingv1.AddToScheme(scheme); err != nil {
+		return err
+	}
+	// the knative pod autoscalers report the number of predictor replicas
+	return autoscalingv1alpha1.AddToScheme(scheme)
+}
+
+func (b *Backend) OwnedTypes() []runtime.Object {
+	return []runtime.Object{&kfservingv1.InferenceService{}}
+}
+
+func (b *Backend) Watches() []reconcilers.Watch {
+	// the pod autoscalers are owned by the knative revisions, but keep the label
+	// set by KFServing on the predictor pods
+	return []reconcilers.Watch{{
+		Type:      &autoscalingv1alpha1.PodAutoscaler{},
+		NameLabel: kfservingv1const.InferenceServicePodLabelKey,
+	}}
+}
+
+func (b *Backend) Build(isvc *servingv1.InferenceService, componentMeta metav1.ObjectMeta,
+	_ *servingv1.OperatorConfig) (reconcilers.Object, error) {
+	if len(isvc.Spec.Graph) > 0 {
+		return nil, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedGraph,
+			"inference graphs are not supported by the %s backend, use the seldon backend instead", BackendName)
+	}
+	if len(isvc.Spec.Variants) > 0 {
+		return nil, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedVariants,
+			"model variants are not supported by the %s backend, use the seldon backend instead", BackendName)
+	}
+	serviceAccountName, err := serviceAccountName(isvc)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutSeconds := int64(60)
+	if isvc.Spec.TimeoutSeconds != nil {
+		timeoutSeconds = *isvc.Spec.TimeoutSeconds
+	}
+	storageURI := isvc.Spec.ModelUri
+	var canaryTrafficPercent *int64
+	if canary := isvc.Spec.Canary; canary != nil {
+		// KFServing rolls out the updated predictor as the canary, keeping the
+		// previously rolled out revision as the stable one
+		storageURI = canary.ModelUri
+		percent := int64(canary.TrafficPercent)
+		canaryTrafficPercent = &percent
+	}
+
+	spec := kfservingv1.InferenceServiceSpec{
+		Predictor: kfservingv1.PredictorSpec{
+			ComponentExtensionSpec: kfservingv1.ComponentExtensionSpec{
+				TimeoutSeconds:       &timeoutSeconds,
+				CanaryTrafficPercent: canaryTrafficPercent,
+			},
+			PodSpec: kfservingv1.PodSpec{
+				ServiceAccountName: serviceAccountName,
+				Volumes:            isvc.Spec.Volumes,
+			},
+		},
+	}
+	applyScheduling(isvc.Spec.Scheduling, &spec.Predictor.PodSpec)
+	if isvc.Spec.Container != nil {
+		setCustomPredictor(isvc, storageURI, &spec.Predictor)
+	} else if err := setModelServer(isvc, storageURI, &spec.Predictor); err != nil {
+		return nil, err
+	}
+	if isvc.Spec.Logging != nil {
+		spec.Predictor.Logger = loggerSpec(isvc.Spec.Logging)
+	}
+	if isvc.Spec.Transformer != nil {
+		spec.Transformer = buildTransformer(isvc, &timeoutSeconds)
+	}
+	if isvc.Spec.Explainer != nil {
+		if spec.Explainer, err = buildExplainer(isvc, serviceAccountName, &timeoutSeconds); err != nil {
+			return nil, err
+		}
+	}
+
+	annotations := make(map[string]string)
+	for k, v := range componentMeta.Annotations {
+		annotations[k] = v
+	}
+	if isvc.Spec.Scaling != nil {
+		if err := applyScaling(isvc.Spec.Scaling, &spec.Predictor, annotations); err != nil {
+			return nil, err
+		}
+	}
+	componentMeta.Annotations = annotations
+
+	if err := reconcilers.ApplyOverrides(BackendName, isvc.Spec.Overrides.ForBackend(BackendName), &spec); err != nil {
+		return nil, err
+	}
+	return createKfservingService(componentMeta, &spec), nil
+}
+
+// applyScheduling sets the scheduling settings on the pods of a component
+func applyScheduling(scheduling *servingv1.SchedulingSpec, podSpec *kfservingv1.PodSpec) {
+	if scheduling == nil {
+		return
+	}
+	podSpec.NodeSelector = scheduling.NodeSelector
+	podSpec.Tolerations = scheduling.Tolerations
+	podSpec.Affinity = scheduling.Affinity
+	podSpec.PriorityClassName = scheduling.PriorityClassName
+}
+
+// loggerSpec returns the KFServing logger capturing the payloads, which sends them to
+// the KFServing default sink when the spec has no URL
+func loggerSpec(logging *servingv1.LoggingSpec) *kfservingv1.LoggerSpec {
+	logger := &kfservingv1.LoggerSpec{Mode: kfservingv1.LoggerType(logging.GetMode())}
+	if logging.URL != "" {
+		url := logging.URL
+		logger.URL = &url
+	}
+	return logger
+}
+
+func (b *Backend) Reconcile(client client.Client, scheme *runtime.Scheme, desired reconcilers.Object) (reconcilers.Object, error) {
+	kfsvcr := &KfservingReconciler{
+		client:  client,
+		scheme:  scheme,
+		Service: desired.(*kfservingv1.InferenceService),
+	}
+	return kfsvcr.Reconcile()
+}
+
+func (b *Backend) PropagateStatus(client client.Client, isvc *servingv1.InferenceService, status *servingv1.InferenceServiceStatus,
+	observed reconcilers.Object) error {
+	service := observed.(*kfservingv1.InferenceService)
+	status.PropagateStatusFromKfserving(&service.Status)
+	if isvc.Spec.Shadow != nil {
+		// KFServing cannot mirror the traffic to a second predictor, the stable model is
+		// served without it
+		status.MarkShadowUnsupported(fmt.Sprintf(
+			"shadow models are not supported by the %s backend, use the seldon backend instead", BackendName))
+	} else {
+		status.ClearShadowStatus()
+	}
+	status.InferencePath = inferencePath(service)
+	status.ExplainURL = nil
+	if service.Spec.Explainer != nil && status.URL != nil {
+		explainURL := *status.URL
+		explainURL.Path = explainPath(service)
+		status.ExplainURL = &explainURL
+	}
+
+	replicas, err := predictorReplicas(client, service)
+	if err != nil {
+		return err
+	}
+	status.Replicas = replicas
+	return nil
+}
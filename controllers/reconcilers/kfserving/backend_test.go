@@ -0,0 +1,199 @@
+package kfserving
+
+import (
+	"testing"
+
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	kfservingv1const "github.com/kubeflow/kfserving/pkg/constants"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// newBackendService returns an inference service of the backend, changed by the given function
+func newBackendService(mutate func(spec *servingv1.InferenceServiceSpec)) *servingv1.InferenceService {
+	isvc := &servingv1.InferenceService{
+		ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default"},
+		Spec: servingv1.InferenceServiceSpec{
+			Backend:   BackendName,
+			ModelUri:  "s3://models/sklearn/iris",
+			Framework: servingv1.FrameworkSKLearn,
+		},
+	}
+	if mutate != nil {
+		mutate(&isvc.Spec)
+	}
+	return isvc
+}
+
+func TestBuildImages(t *testing.T) {
+	config := &servingv1.OperatorConfig{AllowedImages: []string{"kfserving/*"}}
+	overrides := func(patch string) func(spec *servingv1.InferenceServiceSpec) {
+		return func(spec *servingv1.InferenceServiceSpec) {
+			spec.Overrides = &servingv1.OverridesSpec{KFServing: &runtime.RawExtension{Raw: []byte(patch)}}
+		}
+	}
+	tests := []struct {
+		name       string
+		mutate     func(spec *servingv1.InferenceServiceSpec)
+		wantReason string
+	}{
+		{
+			name: "model server selected by kfserving",
+		},
+		{
+			name: "allowed runtime image",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Runtime = &servingv1.RuntimeSpec{Image: "kfserving/sklearnserver:v0.5.1"}
+			},
+		},
+		{
+			name: "runtime image not allowed",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Runtime = &servingv1.RuntimeSpec{Image: "example.com/server:1.0"}
+			},
+			wantReason: reconcilers.ReasonImageNotAllowed,
+		},
+		{
+			name:       "model server image set by the overrides",
+			mutate:     overrides(`{"predictor":{"sklearn":{"image":"example.com/server:1.0"}}}`),
+			wantReason: reconcilers.ReasonImageNotAllowed,
+		},
+		{
+			name:       "container added by the overrides",
+			mutate:     overrides(`{"predictor":{"containers":[{"name":"sidecar","image":"example.com/sidecar:1.0"}]}}`),
+			wantReason: reconcilers.ReasonImageNotAllowed,
+		},
+		{
+			name: "custom container image not allowed",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Container = &v1.Container{Name: "server", Image: "example.com/server:1.0"}
+			},
+			wantReason: reconcilers.ReasonImageNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newBackendService(tt.mutate)
+			componentMeta := metav1.ObjectMeta{Name: isvc.Name, Namespace: isvc.Namespace}
+			object, err := (&Backend{}).Build(isvc, componentMeta, config)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			err = reconcilers.CheckImages(object, config)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("CheckImages() error = %v", err)
+				}
+				return
+			}
+			if specErr, ok := reconcilers.AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+				t.Fatalf("CheckImages() error = %v, want reason %s", err, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name                string
+		mutate              func(spec *servingv1.InferenceServiceSpec)
+		wantProtocolVersion kfservingv1const.InferenceServiceProtocol
+		wantGrpcPort        int32
+		wantReason          string
+	}{
+		{
+			name:                "sklearn with default protocol",
+			wantProtocolVersion: kfservingv1const.ProtocolV2,
+		},
+		{
+			name:                "sklearn with V1 protocol",
+			mutate:              func(spec *servingv1.InferenceServiceSpec) { spec.Protocol = servingv1.ProtocolV1 },
+			wantProtocolVersion: kfservingv1const.ProtocolV1,
+		},
+		{
+			name:                "sklearn with gRPC protocol",
+			mutate:              func(spec *servingv1.InferenceServiceSpec) { spec.Protocol = servingv1.ProtocolGRPC },
+			wantProtocolVersion: kfservingv1const.ProtocolV2,
+			wantGrpcPort:        8081,
+		},
+		{
+			name:                "tensorflow with default protocol",
+			mutate:              func(spec *servingv1.InferenceServiceSpec) { spec.Framework = servingv1.FrameworkTensorflow },
+			wantProtocolVersion: kfservingv1const.ProtocolV1,
+		},
+		{
+			name: "tensorflow with V2 protocol",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Framework = servingv1.FrameworkTensorflow
+				spec.Protocol = servingv1.ProtocolV2
+			},
+			wantReason: reconcilers.ReasonUnsupportedProtocol,
+		},
+		{
+			name: "triton with gRPC protocol",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Framework = servingv1.FrameworkTriton
+				spec.Protocol = servingv1.ProtocolGRPC
+			},
+			wantProtocolVersion: kfservingv1const.ProtocolV2,
+			wantGrpcPort:        9000,
+		},
+		{
+			name: "triton with V1 protocol",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Framework = servingv1.FrameworkTriton
+				spec.Protocol = servingv1.ProtocolV1
+			},
+			wantReason: reconcilers.ReasonUnsupportedProtocol,
+		},
+		{
+			name: "model variants",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Variants = []servingv1.VariantSpec{{Name: "a", ModelUri: spec.ModelUri, Weight: 100}}
+			},
+			wantReason: reconcilers.ReasonUnsupportedVariants,
+		},
+		{
+			name: "inference graph",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Graph = []servingv1.GraphNode{{Name: "model", Image: "server:1.0"}}
+			},
+			wantReason: reconcilers.ReasonUnsupportedGraph,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newBackendService(tt.mutate)
+			componentMeta := metav1.ObjectMeta{Name: isvc.Name, Namespace: isvc.Namespace}
+			object, err := (&Backend{}).Build(isvc, componentMeta, nil)
+			if tt.wantReason != "" {
+				if specErr, ok := reconcilers.AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+					t.Fatalf("Build() error = %v, want reason %s", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			predictor := predictorExtension(&object.(*kfservingv1.InferenceService).Spec.Predictor)
+			if predictor.ProtocolVersion == nil || *predictor.ProtocolVersion != tt.wantProtocolVersion {
+				t.Errorf("Build() protocol version = %v, want %s", predictor.ProtocolVersion, tt.wantProtocolVersion)
+			}
+			var grpcPort int32
+			for _, port := range predictor.Container.Ports {
+				if port.Name == grpcPortName {
+					grpcPort = port.ContainerPort
+				}
+			}
+			if grpcPort != tt.wantGrpcPort {
+				t.Errorf("Build() gRPC port = %d, want %d", grpcPort, tt.wantGrpcPort)
+			}
+		})
+	}
+}
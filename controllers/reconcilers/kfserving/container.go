@@ -0,0 +1,30 @@
+package kfserving
+
+import (
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	kfservingv1const "github.com/kubeflow/kfserving/pkg/constants"
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// setCustomPredictor sets the custom container of the spec as the predictor. KFServing
+// downloads the model stored at storageURI for the container when it is passed in the
+// STORAGE_URI environment variable.
+func setCustomPredictor(isvc *servingv1.InferenceService, storageURI string, predictor *kfservingv1.PredictorSpec) {
+	container := isvc.Spec.Container.DeepCopy()
+	if container.Resources.Limits == nil && container.Resources.Requests == nil && isvc.Spec.Resources != nil {
+		container.Resources = *isvc.Spec.Resources
+	}
+	env := []v1.EnvVar{{Name: kfservingv1const.CustomSpecStorageUriEnvVarKey, Value: storageURI}}
+	for _, envVar := range container.Env {
+		if envVar.Name != kfservingv1const.CustomSpecStorageUriEnvVarKey {
+			env = append(env, envVar)
+		}
+	}
+	container.Env = env
+	appendArguments(isvc, container)
+	reconcilers.ApplyEnvironment(&isvc.Spec, container)
+	predictor.PodSpec.Containers = []v1.Container{*container}
+}
@@ -0,0 +1,51 @@
+package kfserving
+
+import (
+	"fmt"
+
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// explainers maps the explanation methods to the types of the KFServing Alibi explainer
+var explainers = map[servingv1.ExplainerType]kfservingv1.AlibiExplainerType{
+	servingv1.ExplainerAnchorTabular:   kfservingv1.AlibiAnchorsTabularExplainer,
+	servingv1.ExplainerAnchorImages:    kfservingv1.AlibiAnchorsImageExplainer,
+	servingv1.ExplainerAnchorText:      kfservingv1.AlibiAnchorsTextExplainer,
+	servingv1.ExplainerCounterfactuals: kfservingv1.AlibiCounterfactualsExplainer,
+	servingv1.ExplainerContrastive:     kfservingv1.AlibiContrastiveExplainer,
+}
+
+// buildExplainer returns the explainer of the spec as a KFServing Alibi explainer, which
+// fetches the trained explainer with the service account of the predictor
+func buildExplainer(isvc *servingv1.InferenceService, serviceAccountName string, timeoutSeconds *int64) (*kfservingv1.ExplainerSpec, error) {
+	explainerType, ok := explainers[isvc.Spec.Explainer.Type]
+	if !ok {
+		return nil, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedExplainer,
+			"explainer %q is not supported by the %s backend", isvc.Spec.Explainer.Type, BackendName)
+	}
+
+	explainer := &kfservingv1.ExplainerSpec{
+		Alibi: &kfservingv1.AlibiExplainerSpec{
+			Type:       explainerType,
+			StorageURI: isvc.Spec.Explainer.StorageUri,
+			Config:     isvc.Spec.Explainer.Config,
+		},
+		PodSpec: kfservingv1.PodSpec{
+			ServiceAccountName: serviceAccountName,
+		},
+		ComponentExtensionSpec: kfservingv1.ComponentExtensionSpec{
+			TimeoutSeconds: timeoutSeconds,
+		},
+	}
+	applyScheduling(isvc.Spec.Scheduling, &explainer.PodSpec)
+	return explainer, nil
+}
+
+// explainPath returns the path of the explain endpoint of the service, which KFServing
+// routes to the explainer
+func explainPath(service *kfservingv1.InferenceService) string {
+	return fmt.Sprintf("/v1/models/%s:explain", service.Name)
+}
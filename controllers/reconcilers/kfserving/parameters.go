@@ -0,0 +1,45 @@
+package kfserving
+
+import (
+	"fmt"
+
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	kfservingv1const "github.com/kubeflow/kfserving/pkg/constants"
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+	"fuseml.suse/controllers/utils"
+)
+
+// serverArguments lists the parameters accepted as command line arguments by the V1
+// protocol model servers of each framework. The V2 protocol servers accept none.
+var serverArguments = map[servingv1.Framework][]string{
+	servingv1.FrameworkSKLearn:  {"workers", "max_buffer_size", "max_asyncio_workers"},
+	servingv1.FrameworkXGBoost:  {"workers", "max_buffer_size", "max_asyncio_workers", "nthread"},
+	servingv1.FrameworkLightGBM: {"workers", "max_buffer_size", "max_asyncio_workers", "nthread"},
+}
+
+// applyParameters passes the parameters of the spec to the model server as command line
+// arguments, or returns a SpecError when the server does not accept them
+func applyParameters(isvc *servingv1.InferenceService, framework servingv1.Framework, extension *kfservingv1.PredictorExtensionSpec) error {
+	supported := serverArguments[framework]
+	if extension.ProtocolVersion != nil && *extension.ProtocolVersion != kfservingv1const.ProtocolV1 {
+		supported = nil
+	}
+	for _, key := range reconcilers.ParameterKeys(&isvc.Spec) {
+		if !utils.ContainsString(supported, key) {
+			return reconcilers.NewSpecError(reconcilers.ReasonUnsupportedParameter,
+				"parameter %q is not supported for the %s framework by the %s backend", key, framework, BackendName)
+		}
+	}
+	appendArguments(isvc, &extension.Container)
+	return nil
+}
+
+// appendArguments adds the parameters of the spec to the command line arguments of the container
+func appendArguments(isvc *servingv1.InferenceService, container *v1.Container) {
+	for _, key := range reconcilers.ParameterKeys(&isvc.Spec) {
+		container.Args = append(container.Args, fmt.Sprintf("--%s=%s", key, isvc.Spec.Parameters[key]))
+	}
+}
@@ -0,0 +1,94 @@
+package kfserving
+
+import (
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// predictors maps each framework supported by KFServing to the function that sets
+// the matching predictor implementation
+var predictors = map[servingv1.Framework]func(*kfservingv1.PredictorSpec, kfservingv1.PredictorExtensionSpec){
+	servingv1.FrameworkSKLearn: func(p *kfservingv1.PredictorSpec, ext kfservingv1.PredictorExtensionSpec) {
+		p.SKLearn = &kfservingv1.SKLearnSpec{PredictorExtensionSpec: ext}
+	},
+	servingv1.FrameworkXGBoost: func(p *kfservingv1.PredictorSpec, ext kfservingv1.PredictorExtensionSpec) {
+		p.XGBoost = &kfservingv1.XGBoostSpec{PredictorExtensionSpec: ext}
+	},
+	servingv1.FrameworkLightGBM: func(p *kfservingv1.PredictorSpec, ext kfservingv1.PredictorExtensionSpec) {
+		p.LightGBM = &kfservingv1.LightGBMSpec{PredictorExtensionSpec: ext}
+	},
+	servingv1.FrameworkTensorflow: func(p *kfservingv1.PredictorSpec, ext kfservingv1.PredictorExtensionSpec) {
+		p.Tensorflow = &kfservingv1.TFServingSpec{PredictorExtensionSpec: ext}
+	},
+	servingv1.FrameworkPyTorch: func(p *kfservingv1.PredictorSpec, ext kfservingv1.PredictorExtensionSpec) {
+		p.PyTorch = &kfservingv1.TorchServeSpec{PredictorExtensionSpec: ext}
+	},
+	servingv1.FrameworkONNX: func(p *kfservingv1.PredictorSpec, ext kfservingv1.PredictorExtensionSpec) {
+		p.ONNX = &kfservingv1.ONNXRuntimeSpec{PredictorExtensionSpec: ext}
+	},
+	servingv1.FrameworkTriton: func(p *kfservingv1.PredictorSpec, ext kfservingv1.PredictorExtensionSpec) {
+		p.Triton = &kfservingv1.TritonSpec{PredictorExtensionSpec: ext}
+	},
+}
+
+// setModelServer sets the predictor implementation serving the model stored at storageURI
+// with the model server of the framework
+func setModelServer(isvc *servingv1.InferenceService, storageURI string, predictor *kfservingv1.PredictorSpec) error {
+	framework := isvc.Spec.GetFramework()
+	setPredictor, ok := predictors[framework]
+	if !ok {
+		return reconcilers.NewSpecError(reconcilers.ReasonUnsupportedFramework,
+			"framework %q is not supported by the %s backend", framework, BackendName)
+	}
+
+	extension := kfservingv1.PredictorExtensionSpec{
+		StorageURI: &storageURI,
+		Container: v1.Container{
+			Name: "kfserving-container",
+		},
+	}
+	if isvc.Spec.Resources != nil {
+		extension.Container.Resources = *isvc.Spec.Resources
+	}
+	if err := applyProtocol(framework, isvc.Spec.Protocol, &extension); err != nil {
+		return err
+	}
+	if runtime := isvc.Spec.Runtime; runtime != nil {
+		// KFServing prefers the image to the version when both are set
+		if runtime.Version != "" {
+			runtimeVersion := runtime.Version
+			extension.RuntimeVersion = &runtimeVersion
+		}
+		extension.Container.Image = runtime.Image
+	}
+	if err := applyParameters(isvc, framework, &extension); err != nil {
+		return err
+	}
+	reconcilers.ApplyEnvironment(&isvc.Spec, &extension.Container)
+	setPredictor(predictor, extension)
+	return nil
+}
+
+// predictorExtension returns the extension spec of the predictor implementation, if any
+func predictorExtension(p *kfservingv1.PredictorSpec) *kfservingv1.PredictorExtensionSpec {
+	switch {
+	case p.SKLearn != nil:
+		return &p.SKLearn.PredictorExtensionSpec
+	case p.XGBoost != nil:
+		return &p.XGBoost.PredictorExtensionSpec
+	case p.LightGBM != nil:
+		return &p.LightGBM.PredictorExtensionSpec
+	case p.Tensorflow != nil:
+		return &p.Tensorflow.PredictorExtensionSpec
+	case p.PyTorch != nil:
+		return &p.PyTorch.PredictorExtensionSpec
+	case p.ONNX != nil:
+		return &p.ONNX.PredictorExtensionSpec
+	case p.Triton != nil:
+		return &p.Triton.PredictorExtensionSpec
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package kfserving
+
+import (
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	kfservingv1const "github.com/kubeflow/kfserving/pkg/constants"
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// protocols lists the protocols served by the model server of each framework,
+// starting with the one used when the spec does not set any
+var protocols = map[servingv1.Framework][]servingv1.Protocol{
+	servingv1.FrameworkSKLearn:    {servingv1.ProtocolV2, servingv1.ProtocolV1, servingv1.ProtocolGRPC},
+	servingv1.FrameworkXGBoost:    {servingv1.ProtocolV2, servingv1.ProtocolV1, servingv1.ProtocolGRPC},
+	servingv1.FrameworkLightGBM:   {servingv1.ProtocolV1},
+	servingv1.FrameworkTensorflow: {servingv1.ProtocolV1},
+	servingv1.FrameworkPyTorch:    {servingv1.ProtocolV1},
+	servingv1.FrameworkONNX:       {servingv1.ProtocolV1},
+	servingv1.FrameworkTriton:     {servingv1.ProtocolV2, servingv1.ProtocolGRPC},
+}
+
+// grpcPorts maps the frameworks served over gRPC to the port of their gRPC endpoint
+var grpcPorts = map[servingv1.Framework]int32{
+	servingv1.FrameworkSKLearn: 8081,
+	servingv1.FrameworkXGBoost: 8081,
+	servingv1.FrameworkTriton:  9000,
+}
+
+// mlserverVersion is the MLServer release serving sklearn and xgboost models over the V2 protocol
+const mlserverVersion = "0.2.1"
+
+// grpcPortName is the port name knative routes HTTP/2 traffic to
+const grpcPortName = "h2c"
+
+// grpcInferMethod is the inference method of the V2 protocol gRPC service
+const grpcInferMethod = "inference.GRPCInferenceService/ModelInfer"
+
+// applyProtocol sets the protocol served by the predictor, or returns a SpecError when
+// the model server of the framework does not implement it
+func applyProtocol(framework servingv1.Framework, protocol servingv1.Protocol, predictor *kfservingv1.PredictorExtensionSpec) error {
+	supported := protocols[framework]
+	if protocol == "" {
+		protocol = supported[0]
+	}
+	if !reconcilers.ContainsProtocol(supported, protocol) {
+		return reconcilers.NewSpecError(reconcilers.ReasonUnsupportedProtocol,
+			"protocol %s is not supported for the %s framework by the %s backend", protocol, framework, BackendName)
+	}
+
+	protocolVersion := kfservingv1const.ProtocolV1
+	if protocol != servingv1.ProtocolV1 {
+		protocolVersion = kfservingv1const.ProtocolV2
+		// sklearn and xgboost are served by MLServer, which implements the V2 protocol
+		if framework == servingv1.FrameworkSKLearn || framework == servingv1.FrameworkXGBoost {
+			runtimeVersion := mlserverVersion
+			predictor.RuntimeVersion = &runtimeVersion
+		}
+	}
+	predictor.ProtocolVersion = &protocolVersion
+
+	if protocol == servingv1.ProtocolGRPC {
+		// knative exposes a single port, so the predictor only serves gRPC
+		predictor.Container.Ports = []v1.ContainerPort{{
+			Name:          grpcPortName,
+			ContainerPort: grpcPorts[framework],
+			Protocol:      v1.ProtocolTCP,
+		}}
+	}
+	return nil
+}
+
+// inferencePath returns the inference endpoint of the protocol served by the predictor
+func inferencePath(service *kfservingv1.InferenceService) string {
+	predictor := predictorExtension(&service.Spec.Predictor)
+	if predictor == nil {
+		return ""
+	}
+	for _, port := range predictor.Container.Ports {
+		if port.Name == grpcPortName {
+			return grpcInferMethod
+		}
+	}
+	protocolVersion := kfservingv1const.ProtocolV1
+	if predictor.ProtocolVersion != nil {
+		protocolVersion = *predictor.ProtocolVersion
+	}
+	return kfservingv1const.PredictPath(service.Name, protocolVersion)
+}
@@ -14,6 +14,8 @@ import (
 	"knative.dev/pkg/kmp"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"fuseml.suse/controllers/reconcilers"
 )
 
 var log = logf.Log.WithName("KFServingReconciler")
@@ -24,17 +26,6 @@ type KfservingReconciler struct {
 	Service *kfservingv1.InferenceService
 }
 
-func NewKfservingReconciler(client client.Client,
-	scheme *runtime.Scheme,
-	componentMeta metav1.ObjectMeta,
-	isvcSpec *kfservingv1.InferenceServiceSpec) *KfservingReconciler {
-	return &KfservingReconciler{
-		client:  client,
-		scheme:  scheme,
-		Service: createKfservingService(componentMeta, isvcSpec),
-	}
-}
-
 func createKfservingService(componentMeta metav1.ObjectMeta, isvcSpec *kfservingv1.InferenceServiceSpec) *kfservingv1.InferenceService {
 	service := &kfservingv1.InferenceService{
 		ObjectMeta: metav1.ObjectMeta{
@@ -48,7 +39,7 @@ func createKfservingService(componentMeta metav1.ObjectMeta, isvcSpec *kfserving
 	return service
 }
 
-func (r *KfservingReconciler) Reconcile() (*kfservingv1.InferenceServiceStatus, error) {
+func (r *KfservingReconciler) Reconcile() (*kfservingv1.InferenceService, error) {
 	// Create service if does not exist
 	desired := r.Service
 	existing := &kfservingv1.InferenceService{}
@@ -56,36 +47,100 @@ func (r *KfservingReconciler) Reconcile() (*kfservingv1.InferenceServiceStatus,
 	err := r.client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
 	if err != nil {
 		if apierr.IsNotFound(err) {
+			if desired.Spec.Predictor.CanaryTrafficPercent != nil {
+				// the canary would be rolled out as the first and only predictor revision
+				return nil, reconcilers.NewSpecError(reconcilers.ReasonInvalidCanary,
+					"a canary rollout needs a stable model deployed by the %s backend, remove spec.canary until it is ready", BackendName)
+			}
 			log.Info("Creating KFServing inference service", "namespace", desired.Namespace, "name", desired.Name)
-			return &desired.Status, r.client.Create(context.TODO(), desired)
+			return desired, r.client.Create(context.TODO(), desired)
 		}
 		return nil, err
 	}
+	if existing.Spec.Predictor.CanaryTrafficPercent != nil && desired.Spec.Predictor.CanaryTrafficPercent != nil &&
+		predictorStorageUri(&existing.Spec.Predictor) != predictorStorageUri(&desired.Spec.Predictor) {
+		// KFServing would replace the canary revision and lose track of the stable one
+		return existing, reconcilers.NewSpecError(reconcilers.ReasonInvalidCanary,
+			"spec.canary.modelUri cannot be changed while a canary rollout is in progress, promote or abort the canary first")
+	}
 	// Return if no differences to reconcile.
 	if semanticEquals(desired, existing) {
-		return &existing.Status, nil
+		return existing, nil
 	}
 
 	// Reconcile differences and update
-	diff, err := kmp.SafeDiff(desired.Spec.Predictor, existing.Spec.Predictor)
+	diff, err := kmp.SafeDiff(desired.Spec, existing.Spec)
 	if err != nil {
-		return &existing.Status, errors.Wrapf(err, "failed to diff knative service configuration spec")
+		return existing, errors.Wrapf(err, "failed to diff knative service configuration spec")
 	}
 	log.Info("kfserving inference service configuration diff (-desired, +observed):", "diff", diff)
 	existing.Spec.Predictor = desired.Spec.Predictor
+	existing.Spec.Transformer = desired.Spec.Transformer
+	existing.Spec.Explainer = desired.Spec.Explainer
 	existing.ObjectMeta.Labels = desired.ObjectMeta.Labels
+	if existing.ObjectMeta.Annotations == nil {
+		existing.ObjectMeta.Annotations = make(map[string]string)
+	}
+	for k, v := range desired.ObjectMeta.Annotations {
+		existing.ObjectMeta.Annotations[k] = v
+	}
+	for _, k := range staleAnnotations(existing.ObjectMeta.Annotations, desired.ObjectMeta.Annotations) {
+		delete(existing.ObjectMeta.Annotations, k)
+	}
 	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		log.Info("Updating kfserving service", "namespace", desired.Namespace, "name", desired.Name)
 		return r.client.Update(context.TODO(), existing)
 	})
 	if err != nil {
-		return &existing.Status, errors.Wrapf(err, "fails to update knative service")
+		return existing, errors.Wrapf(err, "fails to update knative service")
 	}
-	return &existing.Status, nil
+	return existing, nil
 
 }
 
 func semanticEquals(desiredService, service *kfservingv1.InferenceService) bool {
 	return equality.Semantic.DeepEqual(desiredService.Spec.Predictor, service.Spec.Predictor) &&
-		equality.Semantic.DeepEqual(desiredService.ObjectMeta.Labels, service.ObjectMeta.Labels)
+		equality.Semantic.DeepEqual(desiredService.Spec.Transformer, service.Spec.Transformer) &&
+		equality.Semantic.DeepEqual(desiredService.Spec.Explainer, service.Spec.Explainer) &&
+		equality.Semantic.DeepEqual(desiredService.ObjectMeta.Labels, service.ObjectMeta.Labels) &&
+		containsAnnotations(service.ObjectMeta.Annotations, desiredService.ObjectMeta.Annotations) &&
+		len(staleAnnotations(service.ObjectMeta.Annotations, desiredService.ObjectMeta.Annotations)) == 0
+}
+
+// containsAnnotations checks that all the desired annotations are set, ignoring the
+// ones added to the service by other controllers
+func containsAnnotations(annotations, desired map[string]string) bool {
+	for k, v := range desired {
+		if current, ok := annotations[k]; !ok || current != v {
+			return false
+		}
+	}
+	return true
+}
+
+// staleAnnotations returns the annotations owned by FuseML which are set on the service
+// but no longer desired, e.g. after removing spec.scaling or changing its metric
+func staleAnnotations(annotations, desired map[string]string) []string {
+	var stale []string
+	for _, k := range scalingAnnotationKeys {
+		if _, ok := annotations[k]; !ok {
+			continue
+		}
+		if _, ok := desired[k]; !ok {
+			stale = append(stale, k)
+		}
+	}
+	return stale
+}
+
+// predictorStorageUri returns the URI of the model served by the predictor
+func predictorStorageUri(predictor *kfservingv1.PredictorSpec) string {
+	implementations := predictor.GetImplementations()
+	if len(implementations) == 0 {
+		return ""
+	}
+	if storageUri := implementations[0].GetStorageUri(); storageUri != nil {
+		return *storageUri
+	}
+	return ""
 }
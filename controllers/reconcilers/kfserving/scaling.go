@@ -0,0 +1,95 @@
+package kfserving
+
+import (
+	"context"
+	"strconv"
+
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// scalingAnnotationKeys are the knative autoscaler annotations owned by FuseML, which are
+// removed from the KFServing service when spec.scaling no longer sets them
+var scalingAnnotationKeys = []string{
+	autoscaling.ClassAnnotationKey,
+	autoscaling.MetricAnnotationKey,
+	autoscaling.TargetAnnotationKey,
+}
+
+// applyScaling sets the predictor replica bounds and the knative autoscaler annotations
+func applyScaling(scaling *servingv1.ScalingSpec, predictor *kfservingv1.PredictorSpec, annotations map[string]string) error {
+	if err := scaling.Validate(); err != nil {
+		return reconcilers.NewSpecError(reconcilers.ReasonInvalidScaling, err.Error())
+	}
+
+	minReplicas := int(scaling.GetMinReplicas())
+	predictor.MinReplicas = &minReplicas
+	predictor.MaxReplicas = int(scaling.GetMaxReplicas())
+
+	switch scaling.Metric {
+	case servingv1.ScalingMetricConcurrency:
+		annotations[autoscaling.MetricAnnotationKey] = autoscaling.Concurrency
+	case servingv1.ScalingMetricRPS:
+		annotations[autoscaling.MetricAnnotationKey] = autoscaling.RPS
+	case servingv1.ScalingMetricCPU:
+		// only the HPA autoscaler class is able to scale on CPU usage
+		if minReplicas == 0 {
+			return reconcilers.NewSpecError(reconcilers.ReasonUnsupportedScaling,
+				"scale to zero is not supported with the %s metric", scaling.Metric)
+		}
+		annotations[autoscaling.ClassAnnotationKey] = autoscaling.HPA
+		annotations[autoscaling.MetricAnnotationKey] = autoscaling.CPU
+	}
+	if scaling.Target != nil {
+		annotations[autoscaling.TargetAnnotationKey] = strconv.Itoa(int(*scaling.Target))
+	}
+	return nil
+}
+
+// stableRevision returns the predictor revision serving the stable model. During a canary
+// rollout the latest ready revision is the canary, the stable one being the revision of the
+// traffic target that does not follow the latest revision.
+func stableRevision(predictor kfservingv1.ComponentStatusSpec) string {
+	for _, target := range predictor.Traffic {
+		if target.LatestRevision != nil && !*target.LatestRevision && target.RevisionName != "" {
+			return target.RevisionName
+		}
+	}
+	if predictor.LatestRolledoutRevision != "" {
+		return predictor.LatestRolledoutRevision
+	}
+	return predictor.LatestReadyRevision
+}
+
+// predictorReplicas returns the number of replicas of the stable predictor revision, as
+// reported by its knative pod autoscaler
+func predictorReplicas(c client.Client, service *kfservingv1.InferenceService) (int32, error) {
+	predictor, ok := service.Status.Components[kfservingv1.PredictorComponent]
+	if !ok {
+		return 0, nil
+	}
+	revision := stableRevision(predictor)
+	if revision == "" {
+		return 0, nil
+	}
+
+	podAutoscaler := &autoscalingv1alpha1.PodAutoscaler{}
+	namespacedName := types.NamespacedName{Name: revision, Namespace: service.Namespace}
+	if err := c.Get(context.TODO(), namespacedName, podAutoscaler); err != nil {
+		if apierr.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if podAutoscaler.Status.ActualScale == nil {
+		return 0, nil
+	}
+	return *podAutoscaler.Status.ActualScale, nil
+}
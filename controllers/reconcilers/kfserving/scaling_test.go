@@ -0,0 +1,218 @@
+package kfserving
+
+import (
+	"reflect"
+	"testing"
+
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	autoscalingv1alpha1 "knative.dev/serving/pkg/apis/autoscaling/v1alpha1"
+	knservingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestApplyScaling(t *testing.T) {
+	tests := []struct {
+		name            string
+		scaling         servingv1.ScalingSpec
+		wantMin         int
+		wantMax         int
+		wantAnnotations map[string]string
+		wantReason      string
+	}{
+		{
+			name:            "default replicas",
+			wantMin:         1,
+			wantMax:         1,
+			wantAnnotations: map[string]string{},
+		},
+		{
+			name:    "scale to zero on concurrency",
+			scaling: servingv1.ScalingSpec{MinReplicas: int32Ptr(0), MaxReplicas: int32Ptr(5), Metric: servingv1.ScalingMetricConcurrency, Target: int32Ptr(10)},
+			wantMin: 0,
+			wantMax: 5,
+			wantAnnotations: map[string]string{
+				autoscaling.MetricAnnotationKey: autoscaling.Concurrency,
+				autoscaling.TargetAnnotationKey: "10",
+			},
+		},
+		{
+			name:            "requests per second",
+			scaling:         servingv1.ScalingSpec{MinReplicas: int32Ptr(1), MaxReplicas: int32Ptr(3), Metric: servingv1.ScalingMetricRPS},
+			wantMin:         1,
+			wantMax:         3,
+			wantAnnotations: map[string]string{autoscaling.MetricAnnotationKey: autoscaling.RPS},
+		},
+		{
+			name:    "cpu",
+			scaling: servingv1.ScalingSpec{MinReplicas: int32Ptr(2), MaxReplicas: int32Ptr(4), Metric: servingv1.ScalingMetricCPU, Target: int32Ptr(70)},
+			wantMin: 2,
+			wantMax: 4,
+			wantAnnotations: map[string]string{
+				autoscaling.ClassAnnotationKey:  autoscaling.HPA,
+				autoscaling.MetricAnnotationKey: autoscaling.CPU,
+				autoscaling.TargetAnnotationKey: "70",
+			},
+		},
+		{
+			name:       "cpu with scale to zero",
+			scaling:    servingv1.ScalingSpec{MinReplicas: int32Ptr(0), MaxReplicas: int32Ptr(4), Metric: servingv1.ScalingMetricCPU},
+			wantReason: reconcilers.ReasonUnsupportedScaling,
+		},
+		{
+			name:       "min replicas greater than max replicas",
+			scaling:    servingv1.ScalingSpec{MinReplicas: int32Ptr(3), MaxReplicas: int32Ptr(1)},
+			wantReason: reconcilers.ReasonInvalidScaling,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predictor := &kfservingv1.PredictorSpec{}
+			annotations := make(map[string]string)
+			err := applyScaling(&tt.scaling, predictor, annotations)
+			if tt.wantReason != "" {
+				if specErr, ok := reconcilers.AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+					t.Fatalf("applyScaling() error = %v, want reason %s", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyScaling() error = %v", err)
+			}
+			if predictor.MinReplicas == nil || *predictor.MinReplicas != tt.wantMin || predictor.MaxReplicas != tt.wantMax {
+				t.Errorf("applyScaling() replicas = %v..%d, want %d..%d", predictor.MinReplicas, predictor.MaxReplicas, tt.wantMin, tt.wantMax)
+			}
+			if !reflect.DeepEqual(annotations, tt.wantAnnotations) {
+				t.Errorf("applyScaling() annotations = %v, want %v", annotations, tt.wantAnnotations)
+			}
+		})
+	}
+}
+
+func TestStaleAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		desired     map[string]string
+		want        []string
+	}{
+		{
+			name:        "scaling removed",
+			annotations: map[string]string{autoscaling.MetricAnnotationKey: autoscaling.RPS, autoscaling.TargetAnnotationKey: "10", "team": "ml"},
+			desired:     map[string]string{"team": "ml"},
+			want:        []string{autoscaling.MetricAnnotationKey, autoscaling.TargetAnnotationKey},
+		},
+		{
+			name: "metric changed from cpu",
+			annotations: map[string]string{
+				autoscaling.ClassAnnotationKey:  autoscaling.HPA,
+				autoscaling.MetricAnnotationKey: autoscaling.CPU,
+			},
+			desired: map[string]string{autoscaling.MetricAnnotationKey: autoscaling.Concurrency},
+			want:    []string{autoscaling.ClassAnnotationKey},
+		},
+		{
+			name:        "unchanged",
+			annotations: map[string]string{autoscaling.MetricAnnotationKey: autoscaling.RPS},
+			desired:     map[string]string{autoscaling.MetricAnnotationKey: autoscaling.RPS},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := staleAnnotations(tt.annotations, tt.desired); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("staleAnnotations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPredictorReplicas(t *testing.T) {
+	podAutoscaler := func(revision string, scale int32) runtime.Object {
+		return &autoscalingv1alpha1.PodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: revision, Namespace: "default"},
+			Status:     autoscalingv1alpha1.PodAutoscalerStatus{ActualScale: &scale},
+		}
+	}
+	latest := func(latest bool) *bool {
+		return &latest
+	}
+	scheme := runtime.NewScheme()
+	if err := (&Backend{}).AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme,
+		podAutoscaler("iris-predictor-default-00001", 3),
+		podAutoscaler("iris-predictor-default-00002", 1),
+	)
+
+	tests := []struct {
+		name      string
+		predictor *kfservingv1.ComponentStatusSpec
+		want      int32
+	}{
+		{
+			name: "no predictor",
+		},
+		{
+			name:      "first revision",
+			predictor: &kfservingv1.ComponentStatusSpec{LatestReadyRevision: "iris-predictor-default-00001"},
+			want:      3,
+		},
+		{
+			name: "rolled out revision",
+			predictor: &kfservingv1.ComponentStatusSpec{
+				LatestReadyRevision:     "iris-predictor-default-00002",
+				LatestRolledoutRevision: "iris-predictor-default-00001",
+			},
+			want: 3,
+		},
+		{
+			name: "canary rollout",
+			predictor: &kfservingv1.ComponentStatusSpec{
+				LatestReadyRevision: "iris-predictor-default-00002",
+				Traffic: []knservingv1.TrafficTarget{
+					{RevisionName: "iris-predictor-default-00001", LatestRevision: latest(false), Percent: int64Ptr(90)},
+					{RevisionName: "iris-predictor-default-00002", LatestRevision: latest(true), Percent: int64Ptr(10)},
+				},
+			},
+			want: 3,
+		},
+		{
+			name:      "revision without pod autoscaler",
+			predictor: &kfservingv1.ComponentStatusSpec{LatestReadyRevision: "iris-predictor-default-00003"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &kfservingv1.InferenceService{ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default"}}
+			if tt.predictor != nil {
+				service.Status.Components = map[kfservingv1.ComponentType]kfservingv1.ComponentStatusSpec{
+					kfservingv1.PredictorComponent: *tt.predictor,
+				}
+			}
+			got, err := predictorReplicas(c, service)
+			if err != nil {
+				t.Fatalf("predictorReplicas() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("predictorReplicas() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,98 @@
+package kfserving
+
+import (
+	"net/url"
+
+	"github.com/kubeflow/kfserving/pkg/credentials/s3"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// s3SecretKeys maps the keys of the s3 credentials to the keys read by the KFServing
+// credential builder, the keys of the other providers are the same in both
+var s3SecretKeys = map[string]string{
+	servingv1.StorageS3AccessKeyID:     s3.AWSAccessKeyIdName,
+	servingv1.StorageS3SecretAccessKey: s3.AWSSecretAccessKeyName,
+}
+
+// usesStorageServiceAccount returns true when the storage credentials are passed to
+// the storage initializer through a derived service account
+func usesStorageServiceAccount(isvc *servingv1.InferenceService) bool {
+	return reconcilers.HasStorageCredentials(isvc) && isvc.Spec.Storage.Provider != servingv1.StorageProviderPVC
+}
+
+// serviceAccountName returns the service account running the predictor. KFServing reads
+// the storage credentials from the secrets of the predictor service account, so it is
+// replaced by the derived one when the storage needs credentials.
+func serviceAccountName(isvc *servingv1.InferenceService) (string, error) {
+	storage := isvc.Spec.Storage
+	if storage != nil && storage.Endpoint != "" && !usesStorageServiceAccount(isvc) {
+		return "", reconcilers.NewSpecError(reconcilers.ReasonUnsupportedStorage,
+			"the %s backend needs storage credentials to use a custom storage endpoint", BackendName)
+	}
+	if !usesStorageServiceAccount(isvc) {
+		return isvc.Spec.ServiceAccountName, nil
+	}
+	if isvc.Spec.ServiceAccountName != "" {
+		return "", reconcilers.NewSpecError(reconcilers.ReasonConflictingServiceAccount,
+			"the %s backend cannot use both a service account and storage credentials", BackendName)
+	}
+	return reconcilers.StorageResourceName(isvc, BackendName), nil
+}
+
+func (b *Backend) BuildStorage(isvc *servingv1.InferenceService, componentMeta metav1.ObjectMeta, credentials *v1.Secret) ([]reconcilers.Object, error) {
+	if !usesStorageServiceAccount(isvc) || credentials == nil {
+		return nil, nil
+	}
+
+	objectMeta := metav1.ObjectMeta{
+		Name:      reconcilers.StorageResourceName(isvc, BackendName),
+		Namespace: componentMeta.Namespace,
+		Labels:    componentMeta.Labels,
+	}
+	secret := &v1.Secret{
+		ObjectMeta: *objectMeta.DeepCopy(),
+		Type:       v1.SecretTypeOpaque,
+		Data:       make(map[string][]byte),
+	}
+	storage := isvc.Spec.Storage
+	if storage.Provider == servingv1.StorageProviderS3 {
+		for key, kfservingKey := range s3SecretKeys {
+			if value, ok := credentials.Data[key]; ok {
+				secret.Data[kfservingKey] = value
+			}
+		}
+		if storage.Endpoint != "" {
+			secret.Annotations = s3EndpointAnnotations(storage.Endpoint)
+		}
+	} else {
+		for key, value := range credentials.Data {
+			secret.Data[key] = value
+		}
+	}
+
+	serviceAccount := &v1.ServiceAccount{
+		ObjectMeta: *objectMeta.DeepCopy(),
+		Secrets:    []v1.ObjectReference{{Name: secret.Name}},
+	}
+	return []reconcilers.Object{secret, serviceAccount}, nil
+}
+
+// s3EndpointAnnotations returns the secret annotations setting the s3 endpoint, which
+// KFServing expects without the scheme
+func s3EndpointAnnotations(endpoint string) map[string]string {
+	host, useHTTPS := endpoint, "1"
+	if uri, err := url.Parse(endpoint); err == nil && uri.Host != "" {
+		host = uri.Host
+		if uri.Scheme == "http" {
+			useHTTPS = "0"
+		}
+	}
+	return map[string]string{
+		s3.InferenceServiceS3SecretEndpointAnnotation: host,
+		s3.InferenceServiceS3SecretHttpsAnnotation:    useHTTPS,
+	}
+}
@@ -0,0 +1,182 @@
+package kfserving
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubeflow/kfserving/pkg/credentials/s3"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// newStorageService returns an inference service storing its model with the given storage
+func newStorageService(storage *servingv1.StorageSpec) *servingv1.InferenceService {
+	return &servingv1.InferenceService{
+		ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default"},
+		Spec: servingv1.InferenceServiceSpec{
+			ModelUri: "s3://models/iris",
+			Storage:  storage,
+		},
+	}
+}
+
+func TestServiceAccountName(t *testing.T) {
+	credentialsRef := &v1.LocalObjectReference{Name: "credentials"}
+	tests := []struct {
+		name               string
+		storage            *servingv1.StorageSpec
+		serviceAccountName string
+		want               string
+		wantReason         string
+	}{
+		{
+			name:               "no storage",
+			serviceAccountName: "predictor",
+			want:               "predictor",
+		},
+		{
+			name:    "s3 credentials",
+			storage: &servingv1.StorageSpec{Provider: servingv1.StorageProviderS3, CredentialsSecretRef: credentialsRef},
+			want:    "iris-kfserving-storage",
+		},
+		{
+			name:               "pvc credentials",
+			storage:            &servingv1.StorageSpec{Provider: servingv1.StorageProviderPVC, CredentialsSecretRef: credentialsRef},
+			serviceAccountName: "predictor",
+			want:               "predictor",
+		},
+		{
+			name:       "endpoint without credentials",
+			storage:    &servingv1.StorageSpec{Provider: servingv1.StorageProviderS3, Endpoint: "minio:9000"},
+			wantReason: reconcilers.ReasonUnsupportedStorage,
+		},
+		{
+			name:               "service account and credentials",
+			storage:            &servingv1.StorageSpec{Provider: servingv1.StorageProviderS3, CredentialsSecretRef: credentialsRef},
+			serviceAccountName: "predictor",
+			wantReason:         reconcilers.ReasonConflictingServiceAccount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newStorageService(tt.storage)
+			isvc.Spec.ServiceAccountName = tt.serviceAccountName
+			got, err := serviceAccountName(isvc)
+			if tt.wantReason != "" {
+				if specErr, ok := reconcilers.AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+					t.Fatalf("serviceAccountName() error = %v, want reason %s", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("serviceAccountName() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("serviceAccountName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildStorage(t *testing.T) {
+	credentialsRef := &v1.LocalObjectReference{Name: "credentials"}
+	credentials := &v1.Secret{Data: map[string][]byte{
+		servingv1.StorageS3AccessKeyID:     []byte("key"),
+		servingv1.StorageS3SecretAccessKey: []byte("secret"),
+		servingv1.StorageGCSCredentials:    []byte("{}"),
+	}}
+	tests := []struct {
+		name            string
+		storage         *servingv1.StorageSpec
+		credentials     *v1.Secret
+		wantData        map[string][]byte
+		wantAnnotations map[string]string
+	}{
+		{
+			name: "no storage",
+		},
+		{
+			name:        "pvc credentials",
+			storage:     &servingv1.StorageSpec{Provider: servingv1.StorageProviderPVC, CredentialsSecretRef: credentialsRef},
+			credentials: credentials,
+		},
+		{
+			name:    "s3 credentials",
+			storage: &servingv1.StorageSpec{Provider: servingv1.StorageProviderS3, CredentialsSecretRef: credentialsRef},
+			credentials: &v1.Secret{Data: map[string][]byte{
+				servingv1.StorageS3AccessKeyID:     []byte("key"),
+				servingv1.StorageS3SecretAccessKey: []byte("secret"),
+			}},
+			wantData: map[string][]byte{
+				s3.AWSAccessKeyIdName:     []byte("key"),
+				s3.AWSSecretAccessKeyName: []byte("secret"),
+			},
+		},
+		{
+			name: "s3 credentials with http endpoint",
+			storage: &servingv1.StorageSpec{
+				Provider:             servingv1.StorageProviderS3,
+				Endpoint:             "http://minio:9000",
+				CredentialsSecretRef: credentialsRef,
+			},
+			credentials: &v1.Secret{Data: map[string][]byte{
+				servingv1.StorageS3AccessKeyID:     []byte("key"),
+				servingv1.StorageS3SecretAccessKey: []byte("secret"),
+			}},
+			wantData: map[string][]byte{
+				s3.AWSAccessKeyIdName:     []byte("key"),
+				s3.AWSSecretAccessKeyName: []byte("secret"),
+			},
+			wantAnnotations: map[string]string{
+				s3.InferenceServiceS3SecretEndpointAnnotation: "minio:9000",
+				s3.InferenceServiceS3SecretHttpsAnnotation:    "0",
+			},
+		},
+		{
+			name:        "gcs credentials",
+			storage:     &servingv1.StorageSpec{Provider: servingv1.StorageProviderGCS, CredentialsSecretRef: credentialsRef},
+			credentials: &v1.Secret{Data: map[string][]byte{servingv1.StorageGCSCredentials: []byte("{}")}},
+			wantData:    map[string][]byte{servingv1.StorageGCSCredentials: []byte("{}")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newStorageService(tt.storage)
+			componentMeta := metav1.ObjectMeta{Name: isvc.Name, Namespace: isvc.Namespace}
+			resources, err := (&Backend{}).BuildStorage(isvc, componentMeta, tt.credentials)
+			if err != nil {
+				t.Fatalf("BuildStorage() error = %v", err)
+			}
+			if tt.wantData == nil {
+				if len(resources) != 0 {
+					t.Fatalf("BuildStorage() = %v, want no resources", resources)
+				}
+				return
+			}
+			if len(resources) != 2 {
+				t.Fatalf("BuildStorage() returned %d resources, want a secret and a service account", len(resources))
+			}
+
+			secret := resources[0].(*v1.Secret)
+			if secret.Name != "iris-kfserving-storage" || secret.Namespace != "default" {
+				t.Errorf("secret = %s/%s, want default/iris-kfserving-storage", secret.Namespace, secret.Name)
+			}
+			if !reflect.DeepEqual(secret.Data, tt.wantData) {
+				t.Errorf("secret data = %v, want %v", secret.Data, tt.wantData)
+			}
+			if !reflect.DeepEqual(secret.Annotations, tt.wantAnnotations) {
+				t.Errorf("secret annotations = %v, want %v", secret.Annotations, tt.wantAnnotations)
+			}
+			serviceAccount := resources[1].(*v1.ServiceAccount)
+			wantSecrets := []v1.ObjectReference{{Name: secret.Name}}
+			if serviceAccount.Name != secret.Name || !reflect.DeepEqual(serviceAccount.Secrets, wantSecrets) {
+				t.Errorf("service account %s secrets = %v, want %s with %v", serviceAccount.Name, serviceAccount.Secrets, secret.Name, wantSecrets)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package kfserving
+
+import (
+	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// buildTransformer returns the transformer of the spec as a KFServing custom transformer.
+// KFServing passes the model name and the predictor host to the container as arguments.
+func buildTransformer(isvc *servingv1.InferenceService, timeoutSeconds *int64) *kfservingv1.TransformerSpec {
+	container := v1.Container{
+		Name:  "kfserving-container",
+		Image: isvc.Spec.Transformer.Image,
+		Env:   isvc.Spec.Transformer.Env,
+	}
+	if isvc.Spec.Transformer.Resources != nil {
+		container.Resources = *isvc.Spec.Transformer.Resources
+	}
+
+	transformer := &kfservingv1.TransformerSpec{
+		PodSpec: kfservingv1.PodSpec{
+			Containers: []v1.Container{container},
+		},
+		ComponentExtensionSpec: kfservingv1.ComponentExtensionSpec{
+			TimeoutSeconds: timeoutSeconds,
+		},
+	}
+	applyScheduling(isvc.Spec.Scheduling, &transformer.PodSpec)
+	return transformer
+}
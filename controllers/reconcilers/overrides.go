@@ -0,0 +1,37 @@
+package reconcilers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// ApplyOverrides applies the overrides as a strategic merge patch to the backend spec
+// pointed to by spec. Patches that cannot be applied or that set unknown fields are
+// reported as a SpecError.
+func ApplyOverrides(backend string, overrides *runtime.RawExtension, spec interface{}) error {
+	if overrides == nil || len(overrides.Raw) == 0 {
+		return nil
+	}
+	original, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, overrides.Raw, spec)
+	if err != nil {
+		return NewSpecError(ReasonInvalidOverrides, "fails to apply the %s overrides: %v", backend, err)
+	}
+
+	// decode into a zero value so that the fields removed by the patch are cleared
+	value := reflect.New(reflect.TypeOf(spec).Elem())
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(value.Interface()); err != nil {
+		return NewSpecError(ReasonInvalidOverrides, "the %s overrides produce an invalid spec: %v", backend, err)
+	}
+	reflect.ValueOf(spec).Elem().Set(value.Elem())
+	return nil
+}
@@ -0,0 +1,92 @@
+package reconcilers
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	newPodSpec := func() *v1.PodSpec {
+		return &v1.PodSpec{
+			ServiceAccountName: "predictor",
+			Containers: []v1.Container{
+				{Name: "server", Image: "server:1.0"},
+				{Name: "logger", Image: "logger:1.0"},
+			},
+		}
+	}
+	tests := []struct {
+		name       string
+		overrides  *runtime.RawExtension
+		want       *v1.PodSpec
+		wantReason string
+	}{
+		{
+			name: "no overrides",
+			want: newPodSpec(),
+		},
+		{
+			name:      "empty overrides",
+			overrides: &runtime.RawExtension{},
+			want:      newPodSpec(),
+		},
+		{
+			name:      "container merged by name",
+			overrides: &runtime.RawExtension{Raw: []byte(`{"containers":[{"name":"server","image":"server:2.0"}]}`)},
+			want: &v1.PodSpec{
+				ServiceAccountName: "predictor",
+				Containers: []v1.Container{
+					{Name: "server", Image: "server:2.0"},
+					{Name: "logger", Image: "logger:1.0"},
+				},
+			},
+		},
+		{
+			name:      "field removed",
+			overrides: &runtime.RawExtension{Raw: []byte(`{"serviceAccountName":null}`)},
+			want: &v1.PodSpec{
+				Containers: []v1.Container{
+					{Name: "server", Image: "server:1.0"},
+					{Name: "logger", Image: "logger:1.0"},
+				},
+			},
+		},
+		{
+			name:       "malformed patch",
+			overrides:  &runtime.RawExtension{Raw: []byte(`{"containers":`)},
+			wantReason: ReasonInvalidOverrides,
+		},
+		{
+			name:       "unknown field",
+			overrides:  &runtime.RawExtension{Raw: []byte(`{"replicas":2}`)},
+			wantReason: ReasonInvalidOverrides,
+		},
+		{
+			name:       "field of the wrong type",
+			overrides:  &runtime.RawExtension{Raw: []byte(`{"serviceAccountName":2}`)},
+			wantReason: ReasonInvalidOverrides,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := newPodSpec()
+			err := ApplyOverrides("test", tt.overrides, spec)
+			if tt.wantReason != "" {
+				if specErr, ok := AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+					t.Fatalf("ApplyOverrides() error = %v, want reason %s", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyOverrides() error = %v", err)
+			}
+			if !reflect.DeepEqual(spec, tt.want) {
+				t.Errorf("ApplyOverrides() spec = %+v, want %+v", spec, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package reconcilers
+
+import (
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// ContainsProtocol returns true when the protocol is one of the given protocols
+func ContainsProtocol(protocols []servingv1.Protocol, protocol servingv1.Protocol) bool {
+	for _, p := range protocols {
+		if p == protocol {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package reconcilers
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Backend)
+)
+
+// Register makes a backend available to the controller under its name.
+// It panics if a backend with the same name is already registered.
+func Register(backend Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	name := backend.Name()
+	if _, dup := backends[name]; dup {
+		panic(fmt.Sprintf("backend %q is already registered", name))
+	}
+	backends[name] = backend
+}
+
+// Get returns the backend registered under the given name
+func Get(name string) (Backend, bool) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	backend, ok := backends[name]
+	return backend, ok
+}
+
+// Backends returns all the registered backends, sorted by name
+func Backends() []Backend {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	list := make([]Backend, 0, len(backends))
+	for _, backend := range backends {
+		list = append(list, backend)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// Names returns the names of all the registered backends, sorted
+func Names() []string {
+	names := []string{}
+	for _, backend := range Backends() {
+		names = append(names, backend.Name())
+	}
+	return names
+}
@@ -0,0 +1,178 @@
+package seldon
+
+import (
+	"strconv"
+
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// BackendName is the spec.backend value that selects the Seldon Core backend
+const BackendName = "seldon"
+
+// +kubebuilder:rbac:groups=machinelearning.seldon.io,resources=seldondeployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=machinelearning.seldon.io,resources=seldondeployments/status,verbs=get
+
+// Annotations of the seldon deployment spec setting the request timeouts
+const (
+	restTimeoutAnnotation = "seldon.io/rest-timeout"
+	grpcTimeoutAnnotation = "seldon.io/grpc-timeout"
+)
+
+// Backend deploys inference services as Seldon Core SeldonDeployments
+type Backend struct{}
+
+var _ reconcilers.Backend = &Backend{}
+
+func init() {
+	reconcilers.Register(&Backend{})
+}
+
+func (b *Backend) Name() string {
+	return BackendName
+}
+
+func (b *Backend) AddToScheme(scheme *runtime.Scheme) error {
+	return seldonv1.AddToScheme(scheme)
+}
+
+func (b *Backend) OwnedTypes() []runtime.Object {
+	return []runtime.Object{&seldonv1.SeldonDeployment{}}
+}
+
+func (b *Backend) Watches() []reconcilers.Watch {
+	return nil
+}
+
+func (b *Backend) Build(isvc *servingv1.InferenceService, componentMeta metav1.ObjectMeta,
+	config *servingv1.OperatorConfig) (reconcilers.Object, error) {
+	storage, err := newModelStorage(isvc, config)
+	if err != nil {
+		return nil, err
+	}
+
+	framework := isvc.Spec.GetFramework()
+	var predictors []seldonv1.PredictorSpec
+	if len(isvc.Spec.Variants) > 0 {
+		predictors, err = buildVariants(isvc, storage)
+	} else {
+		predictors, err = buildRollout(isvc, framework, storage)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isvc.Spec.Shadow != nil {
+		shadow, err := buildShadow(isvc, framework, storage)
+		if err != nil {
+			return nil, err
+		}
+		predictors = append(predictors, shadow)
+	}
+
+	spec := seldonv1.SeldonDeploymentSpec{
+		Name:       isvc.Name,
+		Predictors: predictors,
+	}
+	if err := applyProtocol(isvc, &spec); err != nil {
+		return nil, err
+	}
+	if isvc.Spec.TimeoutSeconds != nil {
+		// seldon expects the timeouts in milliseconds
+		timeout := strconv.FormatInt(*isvc.Spec.TimeoutSeconds*1000, 10)
+		spec.Annotations = map[string]string{
+			restTimeoutAnnotation: timeout,
+			grpcTimeoutAnnotation: timeout,
+		}
+	}
+	if err := reconcilers.ApplyOverrides(BackendName, isvc.Spec.Overrides.ForBackend(BackendName), &spec); err != nil {
+		return nil, err
+	}
+	return createSeldonService(componentMeta, &spec), nil
+}
+
+// buildRollout returns the predictor serving the stable model and, during a canary
+// rollout, the predictor serving the candidate model with its share of the traffic
+func buildRollout(isvc *servingv1.InferenceService, framework servingv1.Framework, storage modelStorage) ([]seldonv1.PredictorSpec, error) {
+	stable, err := buildPredictor(isvc, isvc.Name, framework, isvc.Spec.ModelUri, storage)
+	if err != nil {
+		return nil, err
+	}
+
+	predictors := []seldonv1.PredictorSpec{stable}
+	if canary := isvc.Spec.Canary; canary != nil {
+		candidate, err := buildPredictor(isvc, servingv1.TrafficTargetCanary, framework, canary.ModelUri, storage)
+		if err != nil {
+			return nil, err
+		}
+		predictors[0].Traffic = 100 - canary.TrafficPercent
+		candidate.Traffic = canary.TrafficPercent
+		predictors = append(predictors, candidate)
+	}
+	return predictors, nil
+}
+
+func (b *Backend) Reconcile(client client.Client, scheme *runtime.Scheme, desired reconcilers.Object) (reconcilers.Object, error) {
+	seldonr := &SeldonReconciler{
+		client:  client,
+		scheme:  scheme,
+		Service: desired.(*seldonv1.SeldonDeployment),
+	}
+	return seldonr.Reconcile()
+}
+
+func (b *Backend) PropagateStatus(_ client.Client, _ *servingv1.InferenceService, status *servingv1.InferenceServiceStatus,
+	observed reconcilers.Object) error {
+	deployment := observed.(*seldonv1.SeldonDeployment)
+	status.PropagateStatusFromSeldon(&deployment.Status)
+	status.InferencePath = inferencePath(deployment)
+	status.ExplainURL = nil
+	status.Nodes = nil
+	status.Replicas = 0
+	status.Variants = nil
+	if len(deployment.Spec.Predictors) > 0 {
+		stable := &deployment.Spec.Predictors[0]
+		if hasTransformer(stable) {
+			// the transformer runs in the pods of the predictor, so it shares its readiness
+			status.Components[servingv1.ComponentTransformer] = status.Components[servingv1.ComponentPredictor]
+		}
+		status.ExplainURL = explainURL(deployment, stable)
+		status.Nodes = graphNodeStatus(deployment, stable)
+		status.Replicas = predictorReplicas(deployment, stable)
+	}
+
+	// seldon does not report the traffic split, which is taken from the predictors instead.
+	// The shadow gets a copy of the traffic rather than a share of it.
+	var predictors []*seldonv1.PredictorSpec
+	status.ClearShadowStatus()
+	for i := range deployment.Spec.Predictors {
+		predictor := &deployment.Spec.Predictors[i]
+		if predictor.Shadow {
+			status.PropagateShadowStatus(predictorStatus(deployment, predictor))
+			continue
+		}
+		if variant, ok := predictor.Labels[variantLabel]; ok {
+			if status.Variants == nil {
+				status.Variants = make(map[string]servingv1.ComponentStatus)
+			}
+			status.Variants[variant] = predictorStatus(deployment, predictor)
+		}
+		predictors = append(predictors, predictor)
+	}
+	status.Traffic = nil
+	for _, predictor := range predictors {
+		target := servingv1.TrafficStatus{Name: predictor.Name, Percent: int64(predictor.Traffic)}
+		if _, variant := predictor.Labels[variantLabel]; !variant && predictor.Name == deployment.Name {
+			target.Name = servingv1.TrafficTargetStable
+		}
+		if len(predictors) == 1 {
+			target.Percent = 100
+		}
+		status.Traffic = append(status.Traffic, target)
+	}
+	return nil
+}
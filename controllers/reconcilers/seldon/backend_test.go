@@ -0,0 +1,356 @@
+package seldon
+
+import (
+	"testing"
+
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// variants returns model variants of the given frameworks sharing the traffic
+func variants(frameworks ...servingv1.Framework) []servingv1.VariantSpec {
+	var variants []servingv1.VariantSpec
+	for i, framework := range frameworks {
+		weight := int32(100 / len(frameworks))
+		if i == 0 {
+			weight += int32(100 % len(frameworks))
+		}
+		variants = append(variants, servingv1.VariantSpec{
+			Name:      string(framework),
+			ModelUri:  testModelUri,
+			Framework: framework,
+			Weight:    weight,
+		})
+	}
+	return variants
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name          string
+		mutate        func(spec *servingv1.InferenceServiceSpec)
+		wantProtocol  seldonv1.Protocol
+		wantTransport seldonv1.Transport
+		wantReason    string
+	}{
+		{
+			name: "sklearn with default protocol",
+		},
+		{
+			name:         "sklearn with V2 protocol",
+			mutate:       func(spec *servingv1.InferenceServiceSpec) { spec.Protocol = servingv1.ProtocolV2 },
+			wantProtocol: seldonv1.ProtocolKfserving,
+		},
+		{
+			name:          "sklearn with gRPC protocol",
+			mutate:        func(spec *servingv1.InferenceServiceSpec) { spec.Protocol = servingv1.ProtocolGRPC },
+			wantTransport: seldonv1.TransportGrpc,
+		},
+		{
+			name:         "triton with default protocol",
+			mutate:       func(spec *servingv1.InferenceServiceSpec) { spec.Framework = servingv1.FrameworkTriton },
+			wantProtocol: seldonv1.ProtocolKfserving,
+		},
+		{
+			name: "triton with gRPC protocol",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Framework = servingv1.FrameworkTriton
+				spec.Protocol = servingv1.ProtocolGRPC
+			},
+			wantProtocol:  seldonv1.ProtocolKfserving,
+			wantTransport: seldonv1.TransportGrpc,
+		},
+		{
+			name: "tensorflow with V2 protocol",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Framework = servingv1.FrameworkTensorflow
+				spec.Protocol = servingv1.ProtocolV2
+			},
+			wantReason: reconcilers.ReasonUnsupportedProtocol,
+		},
+		{
+			name:       "unsupported framework",
+			mutate:     func(spec *servingv1.InferenceServiceSpec) { spec.Framework = servingv1.FrameworkONNX },
+			wantReason: reconcilers.ReasonUnsupportedFramework,
+		},
+		{
+			name: "custom container of an unsupported framework",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Framework = servingv1.FrameworkONNX
+				spec.Protocol = servingv1.ProtocolV2
+				spec.Container = &v1.Container{Name: "server", Image: "server:1.0"}
+			},
+			wantProtocol: seldonv1.ProtocolKfserving,
+		},
+		{
+			name: "variants of supported frameworks with an unsupported spec framework",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Framework = servingv1.FrameworkPyTorch
+				spec.Variants = variants(servingv1.FrameworkSKLearn, servingv1.FrameworkXGBoost)
+			},
+		},
+		{
+			name: "variants with V2 protocol",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Protocol = servingv1.ProtocolV2
+				spec.Variants = variants(servingv1.FrameworkSKLearn, servingv1.FrameworkTriton)
+			},
+			wantProtocol: seldonv1.ProtocolKfserving,
+		},
+		{
+			name: "variants with default protocols that differ",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Variants = variants(servingv1.FrameworkSKLearn, servingv1.FrameworkTriton)
+			},
+			wantReason: reconcilers.ReasonUnsupportedProtocol,
+		},
+		{
+			name: "variants with gRPC protocol of the triton server",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Protocol = servingv1.ProtocolGRPC
+				spec.Variants = variants(servingv1.FrameworkTriton, servingv1.FrameworkTensorflow)
+			},
+			wantReason: reconcilers.ReasonUnsupportedProtocol,
+		},
+		{
+			name: "variant of an unsupported framework",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Variants = variants(servingv1.FrameworkSKLearn, servingv1.FrameworkLightGBM)
+			},
+			wantReason: reconcilers.ReasonUnsupportedFramework,
+		},
+		{
+			name: "graph of images with an unsupported spec framework",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Framework = servingv1.FrameworkONNX
+				spec.Graph = []servingv1.GraphNode{{Name: "model", Image: "server:1.0"}}
+			},
+		},
+		{
+			name: "graph node of its own framework",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Framework = servingv1.FrameworkONNX
+				spec.Graph = []servingv1.GraphNode{
+					{Name: "transformer", Type: servingv1.GraphNodeTransformer, Image: "transformer:1.0", Children: []string{"model"}},
+					{Name: "model", Framework: servingv1.FrameworkTriton},
+				}
+			},
+			wantProtocol: seldonv1.ProtocolKfserving,
+		},
+		{
+			name: "graph node of a framework without the protocol",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Protocol = servingv1.ProtocolV2
+				spec.Graph = []servingv1.GraphNode{
+					{Name: "router", Type: servingv1.GraphNodeRouter, Image: "router:1.0", Children: []string{"a", "b"}},
+					{Name: "a"},
+					{Name: "b", Framework: servingv1.FrameworkTensorflow},
+				}
+			},
+			wantReason: reconcilers.ReasonUnsupportedProtocol,
+		},
+		{
+			name: "graph node of an unsupported framework",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Graph = []servingv1.GraphNode{{Name: "model", Framework: servingv1.FrameworkPyTorch}}
+			},
+			wantReason: reconcilers.ReasonUnsupportedFramework,
+		},
+		{
+			name: "variants served by a graph",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Framework = servingv1.FrameworkONNX
+				spec.Variants = variants(servingv1.FrameworkSKLearn, servingv1.FrameworkXGBoost)
+				spec.Graph = []servingv1.GraphNode{{Name: "model", Image: "server:1.0"}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := &servingv1.InferenceService{
+				ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default"},
+				Spec: servingv1.InferenceServiceSpec{
+					Backend:   BackendName,
+					ModelUri:  testModelUri,
+					Framework: servingv1.FrameworkSKLearn,
+				},
+			}
+			if tt.mutate != nil {
+				tt.mutate(&isvc.Spec)
+				if len(isvc.Spec.Variants) > 0 {
+					isvc.Spec.ModelUri = ""
+				}
+			}
+			componentMeta := metav1.ObjectMeta{Name: isvc.Name, Namespace: isvc.Namespace}
+			object, err := (&Backend{}).Build(isvc, componentMeta, nil)
+			if tt.wantReason != "" {
+				if specErr, ok := reconcilers.AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+					t.Fatalf("Build() error = %v, want reason %s", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			deployment := object.(*seldonv1.SeldonDeployment)
+			if deployment.Spec.Protocol != tt.wantProtocol || deployment.Spec.Transport != tt.wantTransport {
+				t.Errorf("Build() protocol = %q over %q, want %q over %q",
+					deployment.Spec.Protocol, deployment.Spec.Transport, tt.wantProtocol, tt.wantTransport)
+			}
+		})
+	}
+}
+
+func TestBuildImages(t *testing.T) {
+	config := &servingv1.OperatorConfig{AllowedImages: []string{"seldonio/*"}}
+	overrides := func(patch string) func(spec *servingv1.InferenceServiceSpec) {
+		return func(spec *servingv1.InferenceServiceSpec) {
+			spec.Overrides = &servingv1.OverridesSpec{Seldon: &runtime.RawExtension{Raw: []byte(patch)}}
+		}
+	}
+	tests := []struct {
+		name       string
+		mutate     func(spec *servingv1.InferenceServiceSpec)
+		wantReason string
+	}{
+		{
+			name: "prepackaged server selected by seldon",
+		},
+		{
+			name: "allowed runtime image",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Runtime = &servingv1.RuntimeSpec{Image: "seldonio/sklearnserver:1.7.0"}
+			},
+		},
+		{
+			name: "component specs replaced by the overrides",
+			mutate: overrides(`{"predictors":[{"name":"iris","componentSpecs":[{"spec":{"containers":` +
+				`[{"name":"classifier","image":"example.com/server:1.0"}]}}]}]}`),
+			wantReason: reconcilers.ReasonImageNotAllowed,
+		},
+		{
+			name: "graph node image not allowed",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Graph = []servingv1.GraphNode{{Name: "model", Image: "example.com/server:1.0"}}
+			},
+			wantReason: reconcilers.ReasonImageNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := &servingv1.InferenceService{
+				ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default"},
+				Spec: servingv1.InferenceServiceSpec{
+					Backend:   BackendName,
+					ModelUri:  testModelUri,
+					Framework: servingv1.FrameworkSKLearn,
+				},
+			}
+			if tt.mutate != nil {
+				tt.mutate(&isvc.Spec)
+			}
+			componentMeta := metav1.ObjectMeta{Name: isvc.Name, Namespace: isvc.Namespace}
+			object, err := (&Backend{}).Build(isvc, componentMeta, config)
+			if err == nil {
+				err = reconcilers.CheckImages(object, config)
+			}
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("Build() error = %v", err)
+				}
+				return
+			}
+			if specErr, ok := reconcilers.AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+				t.Fatalf("Build() error = %v, want reason %s", err, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestPropagateStatusReplicas(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(spec *servingv1.InferenceServiceSpec)
+		// available returns the available replicas of the deployments of the predictors
+		available    func(predictor, component int) int32
+		wantReplicas int32
+	}{
+		{
+			name:         "stable model",
+			available:    func(_, _ int) int32 { return 2 },
+			wantReplicas: 2,
+		},
+		{
+			name: "canary not counted",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Canary = &servingv1.CanarySpec{ModelUri: testModelUri + "-v2", TrafficPercent: 10}
+			},
+			available:    func(predictor, _ int) int32 { return int32(2 + 3*predictor) },
+			wantReplicas: 2,
+		},
+		{
+			name: "shadow not counted",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Shadow = &servingv1.ShadowSpec{ModelUri: testModelUri + "-v2"}
+			},
+			available:    func(predictor, _ int) int32 { return int32(1 + 4*predictor) },
+			wantReplicas: 1,
+		},
+		{
+			name: "graph limited by its node with the fewest replicas",
+			mutate: func(spec *servingv1.InferenceServiceSpec) {
+				spec.Graph = []servingv1.GraphNode{
+					{Name: "transformer", Type: servingv1.GraphNodeTransformer, Image: "transformer:1.0", Children: []string{"model"}},
+					{Name: "model"},
+				}
+			},
+			available:    func(_, component int) int32 { return int32(3 - 2*component) },
+			wantReplicas: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := &servingv1.InferenceService{
+				ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default"},
+				Spec: servingv1.InferenceServiceSpec{
+					Backend:   BackendName,
+					ModelUri:  testModelUri,
+					Framework: servingv1.FrameworkSKLearn,
+				},
+			}
+			if tt.mutate != nil {
+				tt.mutate(&isvc.Spec)
+			}
+			componentMeta := metav1.ObjectMeta{Name: isvc.Name, Namespace: isvc.Namespace}
+			object, err := (&Backend{}).Build(isvc, componentMeta, nil)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			deployment := object.(*seldonv1.SeldonDeployment)
+			deployment.Status.DeploymentStatus = make(map[string]seldonv1.DeploymentStatus)
+			for i, predictor := range deployment.Spec.Predictors {
+				for j, componentSpec := range predictor.ComponentSpecs {
+					name := seldonv1.GetDeploymentName(deployment, predictor, componentSpec, j)
+					deployment.Status.DeploymentStatus[name] = seldonv1.DeploymentStatus{AvailableReplicas: tt.available(i, j)}
+				}
+			}
+
+			status := &servingv1.InferenceServiceStatus{}
+			status.InitializeConditions()
+			if err := (&Backend{}).PropagateStatus(nil, isvc, status, deployment); err != nil {
+				t.Fatalf("PropagateStatus() error = %v", err)
+			}
+			if status.Replicas != tt.wantReplicas {
+				t.Errorf("PropagateStatus() replicas = %d, want %d", status.Replicas, tt.wantReplicas)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+const (
+	// defaultStorageInitializerImage downloads the models of the custom containers when
+	// the operator configuration does not set a storage initializer image
+	defaultStorageInitializerImage = "gcr.io/kfserving/storage-initializer:v0.4.0"
+	// modelVolumeName is the volume the model is downloaded to
+	modelVolumeName = "model-provision-location"
+	// modelMountPath is where the custom container finds the model
+	modelMountPath = "/mnt/models"
+)
+
+// modelStorage holds the settings of the init containers downloading the models of the
+// custom containers, which seldon only does itself for the prepackaged servers
+type modelStorage struct {
+	// initializerImage is the image of the init containers
+	initializerImage string
+	// envSecretRefName is the secret holding the storage credentials, if any
+	envSecretRefName string
+}
+
+// newModelStorage returns the settings of the init containers downloading the models of
+// the inference service. The storage initializer image is taken from the operator
+// configuration and must be allowed by it when the spec runs custom containers.
+func newModelStorage(isvc *servingv1.InferenceService, config *servingv1.OperatorConfig) (modelStorage, error) {
+	envSecretRefName, err := envSecretRefName(isvc)
+	if err != nil {
+		return modelStorage{}, err
+	}
+	storage := modelStorage{
+		initializerImage: defaultStorageInitializerImage,
+		envSecretRefName: envSecretRefName,
+	}
+	if config != nil && config.StorageInitializerImage != "" {
+		storage.initializerImage = config.StorageInitializerImage
+	}
+	if usesModelInitializer(isvc) && !config.IsImageAllowed(storage.initializerImage) {
+		return modelStorage{}, reconcilers.NewSpecError(reconcilers.ReasonImageNotAllowed,
+			"storage initializer image %q is not in the allowed images of the operator configuration", storage.initializerImage)
+	}
+	return storage, nil
+}
+
+// usesModelInitializer returns true when the spec runs models in custom containers
+func usesModelInitializer(isvc *servingv1.InferenceService) bool {
+	if isvc.Spec.Container != nil {
+		return true
+	}
+	for _, node := range isvc.Spec.Graph {
+		if node.Image != "" && node.GetType() == servingv1.GraphNodeModel {
+			return true
+		}
+	}
+	return false
+}
+
+// customServer returns the MODEL graph node served by the custom container of the spec,
+// and the pod running it with an init container downloading the model stored at modelUri
+func customServer(isvc *servingv1.InferenceService, modelUri string, storage modelStorage) (seldonv1.PredictiveUnit, v1.PodSpec) {
+	container := isvc.Spec.Container.DeepCopy()
+	if container.Resources.Limits == nil && container.Resources.Requests == nil && isvc.Spec.Resources != nil {
+		container.Resources = *isvc.Spec.Resources
+	}
+
+	var podSpec v1.PodSpec
+	addModelInitializer(&podSpec, *container, modelVolumeName, modelUri, storage)
+
+	unitType := seldonv1.MODEL
+	graph := seldonv1.PredictiveUnit{
+		Name:     container.Name,
+		Type:     &unitType,
+		ModelURI: modelUri,
+	}
+	return graph, podSpec
+}
+
+// addModelInitializer adds the container to the pod, together with an init container
+// downloading the model stored at modelUri into the named volume mounted by the container
+func addModelInitializer(podSpec *v1.PodSpec, container v1.Container, volumeName, modelUri string, storage modelStorage) {
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+		Name:      volumeName,
+		MountPath: modelMountPath,
+		ReadOnly:  true,
+	})
+
+	initializer := v1.Container{
+		Name:  container.Name + "-model-initializer",
+		Image: storage.initializerImage,
+		Args:  []string{modelUri, modelMountPath},
+		VolumeMounts: []v1.VolumeMount{{
+			Name:      volumeName,
+			MountPath: modelMountPath,
+		}},
+	}
+	if storage.envSecretRefName != "" {
+		initializer.EnvFrom = []v1.EnvFromSource{{
+			SecretRef: &v1.SecretEnvSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: storage.envSecretRefName},
+			},
+		}}
+	}
+
+	podSpec.Containers = append(podSpec.Containers, container)
+	podSpec.InitContainers = append(podSpec.InitContainers, initializer)
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		Name: volumeName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"knative.dev/pkg/apis"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// explainPath is the path of the explain endpoint of the seldon Alibi explainer
+const explainPath = "/api/v1.0/explain"
+
+// explainers maps the explanation methods to the types of the seldon Alibi explainer
+var explainers = map[servingv1.ExplainerType]seldonv1.AlibiExplainerType{
+	servingv1.ExplainerAnchorTabular:       seldonv1.AlibiAnchorsTabularExplainer,
+	servingv1.ExplainerAnchorImages:        seldonv1.AlibiAnchorsImageExplainer,
+	servingv1.ExplainerAnchorText:          seldonv1.AlibiAnchorsTextExplainer,
+	servingv1.ExplainerCounterfactuals:     seldonv1.AlibiCounterfactualsExplainer,
+	servingv1.ExplainerContrastive:         seldonv1.AlibiContrastiveExplainer,
+	servingv1.ExplainerKernelShap:          seldonv1.AlibiKernelShapExplainer,
+	servingv1.ExplainerIntegratedGradients: seldonv1.AlibiIntegratedGradientsExplainer,
+	servingv1.ExplainerALE:                 seldonv1.AlibiALEExplainer,
+	servingv1.ExplainerTreeShap:            seldonv1.AlibiTreeShap,
+}
+
+// buildExplainer returns the explainer of the spec as a seldon Alibi explainer, which
+// fetches the trained explainer with the storage secret of the model
+func buildExplainer(isvc *servingv1.InferenceService, envSecretRefName string) (*seldonv1.Explainer, error) {
+	explainerType, ok := explainers[isvc.Spec.Explainer.Type]
+	if !ok {
+		return nil, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedExplainer,
+			"explainer %q is not supported by the %s backend", isvc.Spec.Explainer.Type, BackendName)
+	}
+	return &seldonv1.Explainer{
+		Type:               explainerType,
+		ModelUri:           isvc.Spec.Explainer.StorageUri,
+		Config:             isvc.Spec.Explainer.Config,
+		ServiceAccountName: isvc.Spec.ServiceAccountName,
+		EnvSecretRefName:   envSecretRefName,
+	}, nil
+}
+
+// explainURL returns the URL of the explainer of the predictor, nil until seldon
+// reports its service
+func explainURL(deployment *seldonv1.SeldonDeployment, predictor *seldonv1.PredictorSpec) *apis.URL {
+	if predictor.Explainer == nil {
+		return nil
+	}
+	predictorKey := seldonv1.GetPredictorKey(deployment, predictor)
+	for _, service := range deployment.Status.ServiceStatus {
+		if service.ExplainerFor == predictorKey && service.HttpEndpoint != "" {
+			return &apis.URL{Scheme: "http", Host: service.HttpEndpoint, Path: explainPath}
+		}
+	}
+	return nil
+}
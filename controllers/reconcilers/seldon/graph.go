@@ -0,0 +1,127 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// graphNodeLabel labels the pods running a node of the inference graph with the node name
+const graphNodeLabel = "serving.fuseml.suse/graph-node"
+
+// buildGraph returns the root of the inference graph of the spec, and the pods running its
+// nodes. Each node runs in its own pod, so that seldon reports the readiness of every node.
+// The model nodes without their own model serve the model stored at modelUri.
+func buildGraph(isvc *servingv1.InferenceService, modelUri string, storage modelStorage) (seldonv1.PredictiveUnit, []*seldonv1.SeldonPodSpec, error) {
+	nodes := make(map[string]*servingv1.GraphNode)
+	for i := range isvc.Spec.Graph {
+		nodes[isvc.Spec.Graph[i].Name] = &isvc.Spec.Graph[i]
+	}
+
+	var componentSpecs []*seldonv1.SeldonPodSpec
+	var build func(node *servingv1.GraphNode, depth int) (seldonv1.PredictiveUnit, error)
+	build = func(node *servingv1.GraphNode, depth int) (seldonv1.PredictiveUnit, error) {
+		// the webhook rejects graphs that are not trees, this guards against cycles
+		// when it is disabled
+		if depth > len(nodes) {
+			return seldonv1.PredictiveUnit{}, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedGraph,
+				"the inference graph has a cycle through node %q", node.Name)
+		}
+		unit, podSpec, err := graphNode(isvc, node, modelUri, storage)
+		if err != nil {
+			return seldonv1.PredictiveUnit{}, err
+		}
+		componentSpecs = append(componentSpecs, &seldonv1.SeldonPodSpec{
+			Metadata: seldonv1.ObjectMeta{
+				Labels: map[string]string{graphNodeLabel: node.Name},
+			},
+			Spec: podSpec,
+		})
+
+		for _, name := range node.Children {
+			child, ok := nodes[name]
+			if !ok {
+				return seldonv1.PredictiveUnit{}, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedGraph,
+					"node %q of the inference graph has an unknown child %q", node.Name, name)
+			}
+			childUnit, err := build(child, depth+1)
+			if err != nil {
+				return seldonv1.PredictiveUnit{}, err
+			}
+			unit.Children = append(unit.Children, childUnit)
+		}
+		return unit, nil
+	}
+
+	root, err := build(&isvc.Spec.Graph[0], 0)
+	if err != nil {
+		return seldonv1.PredictiveUnit{}, nil, err
+	}
+	return root, componentSpecs, nil
+}
+
+// graphNode returns the seldon graph node of a node of the inference graph and the pod
+// running it, with the node image or with the prepackaged server of the node framework
+func graphNode(isvc *servingv1.InferenceService, node *servingv1.GraphNode, modelUri string,
+	storage modelStorage) (seldonv1.PredictiveUnit, v1.PodSpec, error) {
+	if node.ModelUri != "" {
+		modelUri = node.ModelUri
+	}
+	framework := node.Framework
+	if framework == "" {
+		framework = isvc.Spec.GetFramework()
+	}
+
+	if node.Image == "" {
+		if node.GetType() != servingv1.GraphNodeModel {
+			return seldonv1.PredictiveUnit{}, v1.PodSpec{}, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedGraph,
+				"node %q of the inference graph needs an image to run as a %s", node.Name, node.GetType())
+		}
+		return prepackagedServer(isvc, node.Name, framework, modelUri, storage.envSecretRefName)
+	}
+
+	container := v1.Container{Name: node.Name, Image: node.Image}
+	unitType := seldonv1.PredictiveUnitType(node.GetType())
+	unit := seldonv1.PredictiveUnit{
+		Name: node.Name,
+		Type: &unitType,
+	}
+	var podSpec v1.PodSpec
+	if node.GetType() == servingv1.GraphNodeModel {
+		if isvc.Spec.Resources != nil {
+			container.Resources = *isvc.Spec.Resources
+		}
+		unit.ModelURI = modelUri
+		addModelInitializer(&podSpec, container, modelVolumeName, modelUri, storage)
+	} else {
+		podSpec.Containers = []v1.Container{container}
+	}
+	return unit, podSpec, nil
+}
+
+// graphNodeStatus returns the readiness of the nodes of the inference graph served by
+// the predictor, taken from the deployments running their pods
+func graphNodeStatus(deployment *seldonv1.SeldonDeployment, predictor *seldonv1.PredictorSpec) map[string]servingv1.ComponentStatus {
+	var nodes map[string]servingv1.ComponentStatus
+	for i, componentSpec := range predictor.ComponentSpecs {
+		node, ok := componentSpec.Metadata.Labels[graphNodeLabel]
+		if !ok {
+			continue
+		}
+		if nodes == nil {
+			nodes = make(map[string]servingv1.ComponentStatus)
+		}
+		status := servingv1.ComponentStatus{
+			Ready:  v1.ConditionUnknown,
+			Reason: "DeploymentCreating",
+		}
+		name := seldonv1.GetDeploymentName(deployment, *predictor, componentSpec, i)
+		if deploymentStatus, ok := deployment.Status.DeploymentStatus[name]; ok {
+			status = servingv1.ComponentStatusFromSeldon(&deploymentStatus)
+		}
+		nodes[node] = status
+	}
+	return nodes
+}
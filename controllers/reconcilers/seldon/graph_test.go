@@ -0,0 +1,183 @@
+package seldon
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+const testModelUri = "s3://models/sklearn/iris"
+
+// newGraphService returns an inference service serving the given inference graph
+func newGraphService(graph ...servingv1.GraphNode) *servingv1.InferenceService {
+	return &servingv1.InferenceService{
+		ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default"},
+		Spec: servingv1.InferenceServiceSpec{
+			Backend:   BackendName,
+			ModelUri:  testModelUri,
+			Framework: servingv1.FrameworkSKLearn,
+			Graph:     graph,
+		},
+	}
+}
+
+// unitTree describes the graph rooted at the unit as name(children...)
+func unitTree(unit seldonv1.PredictiveUnit) string {
+	if len(unit.Children) == 0 {
+		return unit.Name
+	}
+	var children []string
+	for _, child := range unit.Children {
+		children = append(children, unitTree(child))
+	}
+	return unit.Name + "(" + strings.Join(children, ",") + ")"
+}
+
+func TestBuildGraph(t *testing.T) {
+	tests := []struct {
+		name       string
+		graph      []servingv1.GraphNode
+		wantTree   string
+		wantPods   []string
+		wantReason string
+	}{
+		{
+			name:     "single model",
+			graph:    []servingv1.GraphNode{{Name: "model"}},
+			wantTree: "model",
+			wantPods: []string{"model"},
+		},
+		{
+			name: "transformer and router",
+			graph: []servingv1.GraphNode{
+				{Name: "transformer", Type: servingv1.GraphNodeTransformer, Image: "transformer:1.0", Children: []string{"router"}},
+				{Name: "router", Type: servingv1.GraphNodeRouter, Image: "router:1.0", Children: []string{"a", "b"}},
+				{Name: "a"},
+				{Name: "b", Framework: servingv1.FrameworkXGBoost},
+			},
+			wantTree: "transformer(router(a,b))",
+			wantPods: []string{"transformer", "router", "a", "b"},
+		},
+		{
+			name:       "unknown child",
+			graph:      []servingv1.GraphNode{{Name: "model", Children: []string{"missing"}}},
+			wantReason: reconcilers.ReasonUnsupportedGraph,
+		},
+		{
+			name: "cycle",
+			graph: []servingv1.GraphNode{
+				{Name: "a", Children: []string{"b"}},
+				{Name: "b", Children: []string{"a"}},
+			},
+			wantReason: reconcilers.ReasonUnsupportedGraph,
+		},
+		{
+			name:       "combiner without image",
+			graph:      []servingv1.GraphNode{{Name: "combiner", Type: servingv1.GraphNodeCombiner}},
+			wantReason: reconcilers.ReasonUnsupportedGraph,
+		},
+		{
+			name:       "unsupported framework",
+			graph:      []servingv1.GraphNode{{Name: "model", Framework: servingv1.FrameworkONNX}},
+			wantReason: reconcilers.ReasonUnsupportedFramework,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newGraphService(tt.graph...)
+			root, componentSpecs, err := buildGraph(isvc, testModelUri, modelStorage{initializerImage: defaultStorageInitializerImage})
+			if tt.wantReason != "" {
+				if specErr, ok := reconcilers.AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+					t.Fatalf("buildGraph() error = %v, want reason %s", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildGraph() error = %v", err)
+			}
+			if got := unitTree(root); got != tt.wantTree {
+				t.Errorf("buildGraph() graph = %s, want %s", got, tt.wantTree)
+			}
+			var pods []string
+			for _, componentSpec := range componentSpecs {
+				pods = append(pods, componentSpec.Metadata.Labels[graphNodeLabel])
+			}
+			if !reflect.DeepEqual(pods, tt.wantPods) {
+				t.Errorf("buildGraph() pods = %v, want %v", pods, tt.wantPods)
+			}
+		})
+	}
+}
+
+func TestGraphNode(t *testing.T) {
+	storage := modelStorage{initializerImage: defaultStorageInitializerImage, envSecretRefName: "iris-seldon-storage"}
+	tests := []struct {
+		name               string
+		node               servingv1.GraphNode
+		wantModelUri       string
+		wantImplementation string
+		wantInitializer    bool
+	}{
+		{
+			name:               "model served by the prepackaged server",
+			node:               servingv1.GraphNode{Name: "model"},
+			wantModelUri:       testModelUri,
+			wantImplementation: prepackagedServers[servingv1.FrameworkSKLearn],
+		},
+		{
+			name:               "model with its own model and framework",
+			node:               servingv1.GraphNode{Name: "model", ModelUri: "s3://models/xgboost/iris", Framework: servingv1.FrameworkXGBoost},
+			wantModelUri:       "s3://models/xgboost/iris",
+			wantImplementation: prepackagedServers[servingv1.FrameworkXGBoost],
+		},
+		{
+			name:            "model with its own image",
+			node:            servingv1.GraphNode{Name: "model", Image: "server:1.0"},
+			wantModelUri:    testModelUri,
+			wantInitializer: true,
+		},
+		{
+			name: "transformer",
+			node: servingv1.GraphNode{Name: "transformer", Type: servingv1.GraphNodeTransformer, Image: "transformer:1.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := newGraphService(tt.node)
+			unit, podSpec, err := graphNode(isvc, &tt.node, testModelUri, storage)
+			if err != nil {
+				t.Fatalf("graphNode() error = %v", err)
+			}
+			if unit.Name != tt.node.Name || unit.ModelURI != tt.wantModelUri {
+				t.Errorf("graphNode() unit = %s serving %q, want %s serving %q", unit.Name, unit.ModelURI, tt.node.Name, tt.wantModelUri)
+			}
+			implementation := ""
+			if unit.Implementation != nil {
+				implementation = string(*unit.Implementation)
+			}
+			if implementation != tt.wantImplementation {
+				t.Errorf("graphNode() implementation = %q, want %q", implementation, tt.wantImplementation)
+			}
+			if len(podSpec.Containers) != 1 || podSpec.Containers[0].Name != tt.node.Name || podSpec.Containers[0].Image != tt.node.Image {
+				t.Errorf("graphNode() containers = %v, want a %s container running %q", podSpec.Containers, tt.node.Name, tt.node.Image)
+			}
+			if gotInitializer := len(podSpec.InitContainers) == 1; gotInitializer != tt.wantInitializer {
+				t.Fatalf("graphNode() init containers = %v, want model initializer %v", podSpec.InitContainers, tt.wantInitializer)
+			}
+			if tt.wantInitializer {
+				initializer := podSpec.InitContainers[0]
+				if initializer.Args[0] != tt.wantModelUri || initializer.EnvFrom[0].SecretRef.Name != storage.envSecretRefName {
+					t.Errorf("graphNode() initializer = %v, want it downloading %s with %s", initializer, tt.wantModelUri, storage.envSecretRefName)
+				}
+			}
+		})
+	}
+}
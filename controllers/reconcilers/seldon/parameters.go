@@ -0,0 +1,50 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+	"fuseml.suse/controllers/utils"
+)
+
+// serverParameters lists the graph parameters accepted by the seldon protocol prepackaged
+// server of each framework. The V2 protocol servers accept none.
+var serverParameters = map[servingv1.Framework][]string{
+	servingv1.FrameworkSKLearn:    {"method"},
+	servingv1.FrameworkTensorflow: {"signature_name", "model_name", "model_input", "model_output"},
+	servingv1.FrameworkMLflow:     {"xtype"},
+}
+
+// applyParameters sets the parameters of the spec on the graph node, or returns a SpecError
+// when the prepackaged server does not accept them. Custom containers accept any parameter.
+func applyParameters(isvc *servingv1.InferenceService, framework servingv1.Framework, graph *seldonv1.PredictiveUnit) error {
+	custom := isvc.Spec.Container != nil
+	supported := serverParameters[framework]
+	if isvc.Spec.Protocol == servingv1.ProtocolV2 {
+		supported = nil
+	}
+	for _, key := range reconcilers.ParameterKeys(&isvc.Spec) {
+		if !custom && !utils.ContainsString(supported, key) {
+			return reconcilers.NewSpecError(reconcilers.ReasonUnsupportedParameter,
+				"parameter %q is not supported for the %s framework by the %s backend", key, framework, BackendName)
+		}
+		setParameter(graph, key, isvc.Spec.Parameters[key])
+	}
+	return nil
+}
+
+// setParameter sets the value of the named graph parameter, adding it when missing
+func setParameter(graph *seldonv1.PredictiveUnit, name, value string) {
+	for i := range graph.Parameters {
+		if graph.Parameters[i].Name == name {
+			graph.Parameters[i].Value = value
+			return
+		}
+	}
+	graph.Parameters = append(graph.Parameters, seldonv1.Parameter{
+		Name:  name,
+		Type:  seldonv1.STRING,
+		Value: value,
+	})
+}
@@ -0,0 +1,179 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	v1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// buildPredictor returns a predictor serving the model stored at modelUri with the
+// custom container of the spec, with the prepackaged server of the given framework or
+// with the inference graph of the spec
+func buildPredictor(isvc *servingv1.InferenceService, name string, framework servingv1.Framework, modelUri string,
+	storage modelStorage) (seldonv1.PredictorSpec, error) {
+	replicas, hpa, err := hpaSpec(isvc.Spec.Scaling)
+	if err != nil {
+		return seldonv1.PredictorSpec{}, err
+	}
+
+	var graph seldonv1.PredictiveUnit
+	var componentSpecs []*seldonv1.SeldonPodSpec
+	if len(isvc.Spec.Graph) > 0 {
+		if len(isvc.Spec.Parameters) > 0 {
+			// the nodes of a graph run different servers, so there is no single server to pass them to
+			return seldonv1.PredictorSpec{}, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedParameter,
+				"model server parameters cannot be combined with an inference graph by the %s backend", BackendName)
+		}
+		graph, componentSpecs, err = buildGraph(isvc, modelUri, storage)
+		if err != nil {
+			return seldonv1.PredictorSpec{}, err
+		}
+	} else {
+		var podSpec v1.PodSpec
+		if isvc.Spec.Container != nil {
+			graph, podSpec = customServer(isvc, modelUri, storage)
+		} else {
+			graph, podSpec, err = prepackagedServer(isvc, "classifier", framework, modelUri, storage.envSecretRefName)
+			if err != nil {
+				return seldonv1.PredictorSpec{}, err
+			}
+		}
+		if err := applyParameters(isvc, framework, &graph); err != nil {
+			return seldonv1.PredictorSpec{}, err
+		}
+		componentSpecs = []*seldonv1.SeldonPodSpec{{Spec: podSpec}}
+	}
+
+	for _, componentSpec := range componentSpecs {
+		podSpec := &componentSpec.Spec
+		podSpec.ServiceAccountName = isvc.Spec.ServiceAccountName
+		podSpec.Volumes = append(podSpec.Volumes, isvc.Spec.Volumes...)
+		if scheduling := isvc.Spec.Scheduling; scheduling != nil {
+			podSpec.NodeSelector = scheduling.NodeSelector
+			podSpec.Tolerations = scheduling.Tolerations
+			podSpec.Affinity = scheduling.Affinity
+			podSpec.PriorityClassName = scheduling.PriorityClassName
+		}
+		reconcilers.ApplyEnvironment(&isvc.Spec, &podSpec.Containers[0])
+		componentSpec.HpaSpec = hpa.DeepCopy()
+	}
+	if isvc.Spec.Logging != nil {
+		graph.Logger = logger(isvc.Spec.Logging)
+	}
+	if isvc.Spec.Transformer != nil {
+		graph = addTransformer(isvc.Spec.Transformer, graph, &componentSpecs[0].Spec)
+	}
+
+	predictor := seldonv1.PredictorSpec{
+		Name:           name,
+		Replicas:       &replicas,
+		Graph:          graph,
+		ComponentSpecs: componentSpecs,
+	}
+	if isvc.Spec.Explainer != nil {
+		if predictor.Explainer, err = buildExplainer(isvc, storage.envSecretRefName); err != nil {
+			return seldonv1.PredictorSpec{}, err
+		}
+	}
+	return predictor, nil
+}
+
+// predictorStatus returns the readiness of a predictor, taken from the deployments
+// running its pods, and the URL of its service
+func predictorStatus(deployment *seldonv1.SeldonDeployment, predictor *seldonv1.PredictorSpec) servingv1.ComponentStatus {
+	status := servingv1.ComponentStatus{Ready: v1.ConditionTrue}
+	for i, componentSpec := range predictor.ComponentSpecs {
+		name := seldonv1.GetDeploymentName(deployment, *predictor, componentSpec, i)
+		deploymentStatus, ok := deployment.Status.DeploymentStatus[name]
+		if !ok {
+			status = servingv1.ComponentStatus{
+				Ready:  v1.ConditionUnknown,
+				Reason: "DeploymentCreating",
+			}
+			break
+		}
+		if status = servingv1.ComponentStatusFromSeldon(&deploymentStatus); status.Ready != v1.ConditionTrue {
+			break
+		}
+	}
+
+	predictorKey := seldonv1.GetPredictorKey(deployment, predictor)
+	if service, ok := deployment.Status.ServiceStatus[predictorKey]; ok && service.HttpEndpoint != "" {
+		status.URL = &apis.URL{Scheme: "http", Host: service.HttpEndpoint}
+	}
+	return status
+}
+
+// predictorReplicas returns the number of available replicas of the predictor. The
+// nodes of a graph run in separate deployments, so the node with the fewest available
+// replicas limits the replicas able to serve the whole graph.
+func predictorReplicas(deployment *seldonv1.SeldonDeployment, predictor *seldonv1.PredictorSpec) int32 {
+	var replicas int32
+	for i, componentSpec := range predictor.ComponentSpecs {
+		name := seldonv1.GetDeploymentName(deployment, *predictor, componentSpec, i)
+		available := deployment.Status.DeploymentStatus[name].AvailableReplicas
+		if i == 0 || available < replicas {
+			replicas = available
+		}
+	}
+	return replicas
+}
+
+// prepackagedServer returns the named graph node serving the model with the prepackaged
+// server of the framework, and the pod overriding the settings of the server container
+func prepackagedServer(isvc *servingv1.InferenceService, name string, framework servingv1.Framework, modelUri string,
+	envSecretRefName string) (seldonv1.PredictiveUnit, v1.PodSpec, error) {
+	server, ok := prepackagedServers[framework]
+	if !ok {
+		return seldonv1.PredictiveUnit{}, v1.PodSpec{}, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedFramework,
+			"framework %q is not supported by the %s backend", framework, BackendName)
+	}
+
+	// seldon selects the prepackaged server image from its own configuration,
+	// so only a full image can override it. The runtime of the spec only applies
+	// to the servers of the spec framework.
+	image := ""
+	if runtime := isvc.Spec.Runtime; runtime != nil && framework == isvc.Spec.GetFramework() {
+		if runtime.Image == "" && runtime.Version != "" {
+			return seldonv1.PredictiveUnit{}, v1.PodSpec{}, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedRuntime,
+				"the %s backend needs runtime.image to select the version of the model server", BackendName)
+		}
+		image = runtime.Image
+	}
+
+	impl := seldonv1.PredictiveUnitImplementation(server)
+	graph := seldonv1.PredictiveUnit{
+		Implementation:   &impl,
+		ModelURI:         modelUri,
+		Name:             name,
+		EnvSecretRefName: envSecretRefName,
+	}
+	if framework == servingv1.FrameworkSKLearn {
+		graph.Parameters = []seldonv1.Parameter{{
+			Name:  "method",
+			Type:  seldonv1.STRING,
+			Value: "predict",
+		}}
+	}
+
+	// the prepackaged server fills in the rest of the container matching the graph node
+	container := v1.Container{Name: graph.Name, Image: image}
+	if isvc.Spec.Resources != nil {
+		container.Resources = *isvc.Spec.Resources
+	}
+	return graph, v1.PodSpec{Containers: []v1.Container{container}}, nil
+}
+
+// logger returns the seldon logger capturing the payloads of the graph node, which sends
+// them to the seldon default sink when the spec has no URL
+func logger(logging *servingv1.LoggingSpec) *seldonv1.Logger {
+	logger := &seldonv1.Logger{Mode: seldonv1.LoggerMode(logging.GetMode())}
+	if logging.URL != "" {
+		url := logging.URL
+		logger.Url = &url
+	}
+	return logger
+}
@@ -0,0 +1,160 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// protocols lists the protocols served by the prepackaged server of each framework,
+// starting with the one used when the spec does not set any
+var protocols = map[servingv1.Framework][]servingv1.Protocol{
+	servingv1.FrameworkSKLearn:    {servingv1.ProtocolV1, servingv1.ProtocolV2, servingv1.ProtocolGRPC},
+	servingv1.FrameworkXGBoost:    {servingv1.ProtocolV1, servingv1.ProtocolV2, servingv1.ProtocolGRPC},
+	servingv1.FrameworkTensorflow: {servingv1.ProtocolV1, servingv1.ProtocolGRPC},
+	servingv1.FrameworkTriton:     {servingv1.ProtocolV2, servingv1.ProtocolGRPC},
+	servingv1.FrameworkMLflow:     {servingv1.ProtocolV1, servingv1.ProtocolV2, servingv1.ProtocolGRPC},
+}
+
+// Inference methods of the gRPC services
+const (
+	seldonGrpcPredictMethod = "seldon.protos.Seldon/Predict"
+	v2GrpcInferMethod       = "inference.GRPCInferenceService/ModelInfer"
+)
+
+// customServerProtocols lists the protocols a custom container can serve
+var customServerProtocols = []servingv1.Protocol{servingv1.ProtocolV1, servingv1.ProtocolV2, servingv1.ProtocolGRPC}
+
+// modelServer is a server of the models of the seldon deployment, either the prepackaged
+// server of a framework or a custom container
+type modelServer struct {
+	framework servingv1.Framework
+	custom    bool
+}
+
+// protocols returns the protocols the server implements, starting with its default
+func (s modelServer) protocols() []servingv1.Protocol {
+	if s.custom {
+		return customServerProtocols
+	}
+	return protocols[s.framework]
+}
+
+func (s modelServer) String() string {
+	if s.custom {
+		return "custom containers"
+	}
+	return "the " + string(s.framework) + " framework"
+}
+
+// modelServers returns the servers of the predictors built for the spec, the variants
+// and the graph nodes being served for their own framework
+func modelServers(isvc *servingv1.InferenceService) []modelServer {
+	frameworks := []servingv1.Framework{isvc.Spec.GetFramework()}
+	if len(isvc.Spec.Variants) > 0 {
+		frameworks = nil
+		for _, variant := range isvc.Spec.Variants {
+			framework := variant.Framework
+			if framework == "" {
+				framework = isvc.Spec.GetFramework()
+			}
+			frameworks = append(frameworks, framework)
+		}
+		if isvc.Spec.Shadow != nil {
+			frameworks = append(frameworks, isvc.Spec.GetFramework())
+		}
+	}
+
+	var servers []modelServer
+	for _, framework := range frameworks {
+		if len(isvc.Spec.Graph) == 0 {
+			servers = append(servers, modelServer{framework: framework, custom: isvc.Spec.Container != nil})
+			continue
+		}
+		// the model nodes without image are served for the node framework, the spec one
+		// being their default
+		for _, node := range isvc.Spec.Graph {
+			server := modelServer{framework: node.Framework, custom: node.Image != ""}
+			if server.framework == "" {
+				server.framework = isvc.Spec.GetFramework()
+			}
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// applyProtocol sets the protocol and transport of the seldon deployment, or returns
+// a SpecError when one of its model servers does not implement them. The protocol is
+// set for the whole deployment, so every server must implement it.
+func applyProtocol(isvc *servingv1.InferenceService, spec *seldonv1.SeldonDeploymentSpec) error {
+	servers := modelServers(isvc)
+	protocol := isvc.Spec.Protocol
+	for _, server := range servers {
+		if len(server.protocols()) == 0 {
+			return reconcilers.NewSpecError(reconcilers.ReasonUnsupportedFramework,
+				"framework %q is not supported by the %s backend", server.framework, BackendName)
+		}
+		// the custom containers implement any protocol, so the default is the one of
+		// the first prepackaged server
+		if protocol == "" && !server.custom {
+			protocol = server.protocols()[0]
+		}
+	}
+	if protocol == "" {
+		protocol = customServerProtocols[0]
+	}
+
+	triton := false
+	for _, server := range servers {
+		if !reconcilers.ContainsProtocol(server.protocols(), protocol) {
+			return reconcilers.NewSpecError(reconcilers.ReasonUnsupportedProtocol,
+				"protocol %s is not supported for %s by the %s backend", protocol, server, BackendName)
+		}
+		triton = triton || (!server.custom && server.framework == servingv1.FrameworkTriton)
+	}
+
+	switch protocol {
+	case servingv1.ProtocolV2:
+		spec.Protocol = seldonv1.ProtocolKfserving
+	case servingv1.ProtocolGRPC:
+		spec.Transport = seldonv1.TransportGrpc
+		if !triton {
+			break
+		}
+		// the triton server only implements the V2 protocol, over gRPC as well, so the
+		// other servers must serve it too
+		for _, server := range servers {
+			if !reconcilers.ContainsProtocol(server.protocols(), servingv1.ProtocolV2) {
+				return reconcilers.NewSpecError(reconcilers.ReasonUnsupportedProtocol,
+					"protocol %s is served with the V2 protocol by the triton server, which is not supported for %s by the %s backend",
+					protocol, server, BackendName)
+			}
+		}
+		spec.Protocol = seldonv1.ProtocolKfserving
+	}
+	return nil
+}
+
+// inferencePath returns the inference endpoint of the protocol served by the deployment
+func inferencePath(deployment *seldonv1.SeldonDeployment) string {
+	if deployment.Spec.Transport == seldonv1.TransportGrpc {
+		if deployment.Spec.Protocol == seldonv1.ProtocolKfserving {
+			return v2GrpcInferMethod
+		}
+		return seldonGrpcPredictMethod
+	}
+	if len(deployment.Spec.Predictors) == 0 {
+		return ""
+	}
+	// the same paths seldon appends to the deployment address
+	graphName := deployment.Spec.Predictors[0].Graph.Name
+	switch deployment.Spec.Protocol {
+	case seldonv1.ProtocolKfserving:
+		return "/v2/models/" + graphName + "/infer"
+	case seldonv1.ProtocolTensorflow:
+		return "/v1/models/" + graphName + "/:predict"
+	}
+	return "/api/v1.0/predictions"
+}
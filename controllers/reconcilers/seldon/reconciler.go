@@ -24,17 +24,6 @@ type SeldonReconciler struct {
 	Service *seldonv1.SeldonDeployment
 }
 
-func NewSeldonReconciler(client client.Client,
-	scheme *runtime.Scheme,
-	componentMeta metav1.ObjectMeta,
-	sDeploymentSpec *seldonv1.SeldonDeploymentSpec) *SeldonReconciler {
-	return &SeldonReconciler{
-		client:  client,
-		scheme:  scheme,
-		Service: createSeldonService(componentMeta, sDeploymentSpec),
-	}
-}
-
 func createSeldonService(componentMeta metav1.ObjectMeta, sDeploymentSpec *seldonv1.SeldonDeploymentSpec) *seldonv1.SeldonDeployment {
 	service := &seldonv1.SeldonDeployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -48,7 +37,7 @@ func createSeldonService(componentMeta metav1.ObjectMeta, sDeploymentSpec *seldo
 	return service
 }
 
-func (r *SeldonReconciler) Reconcile() (*seldonv1.SeldonDeploymentStatus, error) {
+func (r *SeldonReconciler) Reconcile() (*seldonv1.SeldonDeployment, error) {
 	// Create service if does not exist
 	desired := r.Service
 	existing := &seldonv1.SeldonDeployment{}
@@ -57,35 +46,61 @@ func (r *SeldonReconciler) Reconcile() (*seldonv1.SeldonDeploymentStatus, error)
 	if err != nil {
 		if apierr.IsNotFound(err) {
 			log.Info("Creating seldon deployment", "namespace", desired.Namespace, "name", desired.Name)
-			return &desired.Status, r.client.Create(context.TODO(), desired)
+			return desired, r.client.Create(context.TODO(), desired)
 		}
 		return nil, err
 	}
 	// Return if no differences to reconcile.
 	if semanticEquals(desired, existing) {
-		return &existing.Status, nil
+		return existing, nil
 	}
 
 	// Reconcile differences and update
-	diff, err := kmp.SafeDiff(desired.Spec.Predictors, existing.Spec.Predictors)
+	diff, err := kmp.SafeDiff(desired.Spec, existing.Spec)
 	if err != nil {
-		return &existing.Status, errors.Wrapf(err, "failed to diff sledon deplyoment configuration spec")
+		return existing, errors.Wrapf(err, "failed to diff sledon deplyoment configuration spec")
 	}
 	log.Info("seldon deployment configuration diff (-desired, +observed):", "diff", diff)
-	existing.Spec.Predictors = desired.Spec.Predictors
+	// the overrides can set any field of the spec, so it is synced as a whole
+	existing.Spec = desired.Spec
 	existing.ObjectMeta.Labels = desired.ObjectMeta.Labels
 	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		log.Info("Updating seldon deployment", "namespace", desired.Namespace, "name", desired.Name)
 		return r.client.Update(context.TODO(), existing)
 	})
 	if err != nil {
-		return &existing.Status, errors.Wrapf(err, "fails to update seldon deployment")
+		return existing, errors.Wrapf(err, "fails to update seldon deployment")
 	}
-	return &existing.Status, nil
+	return existing, nil
 
 }
 
 func semanticEquals(desiredService, service *seldonv1.SeldonDeployment) bool {
-	return equality.Semantic.DeepEqual(desiredService.Spec.Predictors, service.Spec.Predictors) &&
+	return equality.Semantic.DeepEqual(normalizedSpec(&desiredService.Spec), normalizedSpec(&service.Spec)) &&
 		equality.Semantic.DeepEqual(desiredService.ObjectMeta.Labels, service.ObjectMeta.Labels)
 }
+
+// normalizedSpec returns a copy of the spec with the fields left unset replaced by the
+// values seldon defaults them to
+func normalizedSpec(spec *seldonv1.SeldonDeploymentSpec) *seldonv1.SeldonDeploymentSpec {
+	normalized := spec.DeepCopy()
+	normalized.Protocol = protocolOf(spec)
+	normalized.Transport = transportOf(spec)
+	return normalized
+}
+
+// protocolOf returns the protocol of the deployment, which seldon defaults to its own
+func protocolOf(spec *seldonv1.SeldonDeploymentSpec) seldonv1.Protocol {
+	if spec.Protocol == "" {
+		return seldonv1.ProtocolSeldon
+	}
+	return spec.Protocol
+}
+
+// transportOf returns the transport of the deployment, which seldon defaults to REST
+func transportOf(spec *seldonv1.SeldonDeploymentSpec) seldonv1.Transport {
+	if spec.Transport == "" {
+		return seldonv1.TransportRest
+	}
+	return spec.Transport
+}
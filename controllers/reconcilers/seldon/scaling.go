@@ -0,0 +1,54 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	autoscalingv2beta1 "k8s.io/api/autoscaling/v2beta1"
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// defaultCPUTarget is the average CPU utilization targeted by the HPA when the
+// scaling spec does not set one
+const defaultCPUTarget = int32(80)
+
+// hpaSpec returns the replica count of the predictor and, when the number of
+// replicas is allowed to vary, the HPA spec autoscaling it
+func hpaSpec(scaling *servingv1.ScalingSpec) (int32, *seldonv1.SeldonHpaSpec, error) {
+	if scaling == nil {
+		return 1, nil, nil
+	}
+	if err := scaling.Validate(); err != nil {
+		return 0, nil, reconcilers.NewSpecError(reconcilers.ReasonInvalidScaling, err.Error())
+	}
+
+	minReplicas, maxReplicas := scaling.GetMinReplicas(), scaling.GetMaxReplicas()
+	if minReplicas == 0 {
+		return 0, nil, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedScaling,
+			"scale to zero is not supported by the %s backend", BackendName)
+	}
+	if scaling.Metric != "" && scaling.Metric != servingv1.ScalingMetricCPU {
+		return 0, nil, reconcilers.NewSpecError(reconcilers.ReasonUnsupportedScaling,
+			"scaling metric %q is not supported by the %s backend", scaling.Metric, BackendName)
+	}
+	if minReplicas == maxReplicas {
+		return minReplicas, nil, nil
+	}
+
+	target := defaultCPUTarget
+	if scaling.Target != nil {
+		target = *scaling.Target
+	}
+	return minReplicas, &seldonv1.SeldonHpaSpec{
+		MinReplicas: &minReplicas,
+		MaxReplicas: maxReplicas,
+		Metrics: []autoscalingv2beta1.MetricSpec{{
+			Type: autoscalingv2beta1.ResourceMetricSourceType,
+			Resource: &autoscalingv2beta1.ResourceMetricSource{
+				Name:                     v1.ResourceCPU,
+				TargetAverageUtilization: &target,
+			},
+		}},
+	}, nil
+}
@@ -0,0 +1,95 @@
+package seldon
+
+import (
+	"testing"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestHpaSpec(t *testing.T) {
+	tests := []struct {
+		name         string
+		scaling      *servingv1.ScalingSpec
+		wantReplicas int32
+		wantHpa      bool
+		wantMax      int32
+		wantTarget   int32
+		wantReason   string
+	}{
+		{
+			name:         "no scaling",
+			wantReplicas: 1,
+		},
+		{
+			name:         "fixed replicas",
+			scaling:      &servingv1.ScalingSpec{MinReplicas: int32Ptr(3)},
+			wantReplicas: 3,
+		},
+		{
+			name:         "cpu with default target",
+			scaling:      &servingv1.ScalingSpec{MinReplicas: int32Ptr(1), MaxReplicas: int32Ptr(4)},
+			wantReplicas: 1,
+			wantHpa:      true,
+			wantMax:      4,
+			wantTarget:   defaultCPUTarget,
+		},
+		{
+			name:         "cpu with target",
+			scaling:      &servingv1.ScalingSpec{MinReplicas: int32Ptr(2), MaxReplicas: int32Ptr(5), Metric: servingv1.ScalingMetricCPU, Target: int32Ptr(60)},
+			wantReplicas: 2,
+			wantHpa:      true,
+			wantMax:      5,
+			wantTarget:   60,
+		},
+		{
+			name:       "scale to zero",
+			scaling:    &servingv1.ScalingSpec{MinReplicas: int32Ptr(0), MaxReplicas: int32Ptr(3)},
+			wantReason: reconcilers.ReasonUnsupportedScaling,
+		},
+		{
+			name:       "requests per second",
+			scaling:    &servingv1.ScalingSpec{MinReplicas: int32Ptr(1), MaxReplicas: int32Ptr(3), Metric: servingv1.ScalingMetricRPS},
+			wantReason: reconcilers.ReasonUnsupportedScaling,
+		},
+		{
+			name:       "min replicas greater than max replicas",
+			scaling:    &servingv1.ScalingSpec{MinReplicas: int32Ptr(3), MaxReplicas: int32Ptr(1)},
+			wantReason: reconcilers.ReasonInvalidScaling,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replicas, hpa, err := hpaSpec(tt.scaling)
+			if tt.wantReason != "" {
+				if specErr, ok := reconcilers.AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+					t.Fatalf("hpaSpec() error = %v, want reason %s", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("hpaSpec() error = %v", err)
+			}
+			if replicas != tt.wantReplicas {
+				t.Errorf("hpaSpec() replicas = %d, want %d", replicas, tt.wantReplicas)
+			}
+			if (hpa != nil) != tt.wantHpa {
+				t.Fatalf("hpaSpec() hpa = %v, want hpa %v", hpa, tt.wantHpa)
+			}
+			if hpa == nil {
+				return
+			}
+			if *hpa.MinReplicas != tt.wantReplicas || hpa.MaxReplicas != tt.wantMax {
+				t.Errorf("hpaSpec() hpa replicas = %d..%d, want %d..%d", *hpa.MinReplicas, hpa.MaxReplicas, tt.wantReplicas, tt.wantMax)
+			}
+			if target := *hpa.Metrics[0].Resource.TargetAverageUtilization; target != tt.wantTarget {
+				t.Errorf("hpaSpec() cpu target = %d, want %d", target, tt.wantTarget)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	seldonv1const "github.com/seldonio/seldon-core/operator/constants"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// prepackagedServers maps each framework supported by Seldon Core to the
+// prepackaged model server implementation serving it
+var prepackagedServers = map[servingv1.Framework]string{
+	servingv1.FrameworkSKLearn:    seldonv1const.PrePackedServerSklearn,
+	servingv1.FrameworkXGBoost:    seldonv1.PrepackXgboostName,
+	servingv1.FrameworkTensorflow: seldonv1const.PrePackedServerTensorflow,
+	servingv1.FrameworkTriton:     seldonv1const.PrePackedServerTriton,
+	servingv1.FrameworkMLflow:     seldonv1const.PrePackedMlflow,
+}
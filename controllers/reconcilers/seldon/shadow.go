@@ -0,0 +1,23 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// shadowPredictorName is the name of the predictor serving the shadow model
+const shadowPredictorName = "shadow"
+
+// buildShadow returns the predictor serving the shadow model of the spec. Seldon sends it
+// a copy of the requests and discards its responses.
+func buildShadow(isvc *servingv1.InferenceService, framework servingv1.Framework, storage modelStorage) (seldonv1.PredictorSpec, error) {
+	shadow, err := buildPredictor(isvc, shadowPredictorName, framework, isvc.Spec.Shadow.ModelUri, storage)
+	if err != nil {
+		return seldonv1.PredictorSpec{}, err
+	}
+	shadow.Shadow = true
+	// the explanations are only served for the stable model
+	shadow.Explainer = nil
+	return shadow, nil
+}
@@ -0,0 +1,108 @@
+package seldon
+
+import (
+	"net/url"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// usesStorageSecret returns true when the storage settings are passed to the storage
+// initializer through a derived environment secret
+func usesStorageSecret(isvc *servingv1.InferenceService) bool {
+	storage := isvc.Spec.Storage
+	if storage == nil {
+		return false
+	}
+	switch storage.Provider {
+	case servingv1.StorageProviderS3:
+		return storage.CredentialsSecretRef != nil || storage.Endpoint != ""
+	case servingv1.StorageProviderGCS, servingv1.StorageProviderAzure, servingv1.StorageProviderHTTP:
+		return storage.CredentialsSecretRef != nil
+	}
+	return false
+}
+
+// envSecretRefName returns the secret holding the environment of the storage initializer
+func envSecretRefName(isvc *servingv1.InferenceService) (string, error) {
+	if !usesStorageSecret(isvc) {
+		return "", nil
+	}
+	if isvc.Spec.Storage.Provider == servingv1.StorageProviderHTTP {
+		return "", reconcilers.NewSpecError(reconcilers.ReasonUnsupportedStorage,
+			"the %s backend does not support credentials for the %s storage provider", BackendName, servingv1.StorageProviderHTTP)
+	}
+	return reconcilers.StorageResourceName(isvc, BackendName), nil
+}
+
+func (b *Backend) BuildStorage(isvc *servingv1.InferenceService, componentMeta metav1.ObjectMeta, credentials *v1.Secret) ([]reconcilers.Object, error) {
+	name, err := envSecretRefName(isvc)
+	if err != nil || name == "" {
+		return nil, err
+	}
+
+	storage := isvc.Spec.Storage
+	data := make(map[string][]byte)
+	credential := func(key string) []byte {
+		if credentials == nil {
+			return nil
+		}
+		return credentials.Data[key]
+	}
+	switch storage.Provider {
+	case servingv1.StorageProviderS3:
+		// both the environment of the kfserving storage initializer and the rclone one
+		data["RCLONE_CONFIG_S3_TYPE"] = []byte("s3")
+		data["RCLONE_CONFIG_S3_PROVIDER"] = []byte("AWS")
+		data["RCLONE_CONFIG_S3_ENV_AUTH"] = []byte("false")
+		if credentials != nil {
+			data[servingv1.StorageS3AccessKeyID] = credential(servingv1.StorageS3AccessKeyID)
+			data[servingv1.StorageS3SecretAccessKey] = credential(servingv1.StorageS3SecretAccessKey)
+			data["RCLONE_CONFIG_S3_ACCESS_KEY_ID"] = credential(servingv1.StorageS3AccessKeyID)
+			data["RCLONE_CONFIG_S3_SECRET_ACCESS_KEY"] = credential(servingv1.StorageS3SecretAccessKey)
+		} else {
+			data["RCLONE_CONFIG_S3_ENV_AUTH"] = []byte("true")
+		}
+		if storage.Endpoint != "" {
+			endpoint, useHTTPS := storage.Endpoint, "1"
+			if uri, err := url.Parse(storage.Endpoint); err == nil && uri.Host != "" {
+				if uri.Scheme == "http" {
+					useHTTPS = "0"
+				}
+			} else {
+				endpoint = "https://" + storage.Endpoint
+			}
+			data["AWS_ENDPOINT_URL"] = []byte(endpoint)
+			data["S3_USE_HTTPS"] = []byte(useHTTPS)
+			data["RCLONE_CONFIG_S3_PROVIDER"] = []byte("Minio")
+			data["RCLONE_CONFIG_S3_ENDPOINT"] = []byte(endpoint)
+		}
+	case servingv1.StorageProviderGCS:
+		data["RCLONE_CONFIG_GS_TYPE"] = []byte("google cloud storage")
+		data["RCLONE_CONFIG_GS_ANONYMOUS"] = []byte("false")
+		data["RCLONE_CONFIG_GS_SERVICE_ACCOUNT_CREDENTIALS"] = credential(servingv1.StorageGCSCredentials)
+	case servingv1.StorageProviderAzure:
+		for _, key := range []string{
+			servingv1.StorageAzureSubscriptionID,
+			servingv1.StorageAzureTenantID,
+			servingv1.StorageAzureClientID,
+			servingv1.StorageAzureClientSecret,
+		} {
+			data[key] = credential(key)
+		}
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: componentMeta.Namespace,
+			Labels:    componentMeta.Labels,
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: data,
+	}
+	return []reconcilers.Object{secret}, nil
+}
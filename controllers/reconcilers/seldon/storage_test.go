@@ -0,0 +1,155 @@
+package seldon
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+func TestBuildStorage(t *testing.T) {
+	credentialsRef := &v1.LocalObjectReference{Name: "credentials"}
+	s3Credentials := &v1.Secret{Data: map[string][]byte{
+		servingv1.StorageS3AccessKeyID:     []byte("key"),
+		servingv1.StorageS3SecretAccessKey: []byte("secret"),
+	}}
+	tests := []struct {
+		name        string
+		modelUri    string
+		storage     *servingv1.StorageSpec
+		credentials *v1.Secret
+		want        map[string][]byte
+		wantReason  string
+	}{
+		{
+			name:     "no storage",
+			modelUri: "s3://models/iris",
+		},
+		{
+			name:     "s3 without credentials nor endpoint",
+			modelUri: "s3://models/iris",
+			storage:  &servingv1.StorageSpec{Provider: servingv1.StorageProviderS3},
+		},
+		{
+			name:        "s3 credentials",
+			modelUri:    "s3://models/iris",
+			storage:     &servingv1.StorageSpec{Provider: servingv1.StorageProviderS3, CredentialsSecretRef: credentialsRef},
+			credentials: s3Credentials,
+			want: map[string][]byte{
+				"RCLONE_CONFIG_S3_TYPE":              []byte("s3"),
+				"RCLONE_CONFIG_S3_PROVIDER":          []byte("AWS"),
+				"RCLONE_CONFIG_S3_ENV_AUTH":          []byte("false"),
+				"RCLONE_CONFIG_S3_ACCESS_KEY_ID":     []byte("key"),
+				"RCLONE_CONFIG_S3_SECRET_ACCESS_KEY": []byte("secret"),
+				servingv1.StorageS3AccessKeyID:       []byte("key"),
+				servingv1.StorageS3SecretAccessKey:   []byte("secret"),
+			},
+		},
+		{
+			name:        "s3 credentials with http endpoint",
+			modelUri:    "s3://models/iris",
+			storage:     &servingv1.StorageSpec{Provider: servingv1.StorageProviderS3, Endpoint: "http://minio:9000", CredentialsSecretRef: credentialsRef},
+			credentials: s3Credentials,
+			want: map[string][]byte{
+				"RCLONE_CONFIG_S3_TYPE":              []byte("s3"),
+				"RCLONE_CONFIG_S3_PROVIDER":          []byte("Minio"),
+				"RCLONE_CONFIG_S3_ENV_AUTH":          []byte("false"),
+				"RCLONE_CONFIG_S3_ENDPOINT":          []byte("http://minio:9000"),
+				"RCLONE_CONFIG_S3_ACCESS_KEY_ID":     []byte("key"),
+				"RCLONE_CONFIG_S3_SECRET_ACCESS_KEY": []byte("secret"),
+				servingv1.StorageS3AccessKeyID:       []byte("key"),
+				servingv1.StorageS3SecretAccessKey:   []byte("secret"),
+				"AWS_ENDPOINT_URL":                   []byte("http://minio:9000"),
+				"S3_USE_HTTPS":                       []byte("0"),
+			},
+		},
+		{
+			name:     "s3 endpoint without scheme nor credentials",
+			modelUri: "s3://models/iris",
+			storage:  &servingv1.StorageSpec{Provider: servingv1.StorageProviderS3, Endpoint: "minio:9000"},
+			want: map[string][]byte{
+				"RCLONE_CONFIG_S3_TYPE":     []byte("s3"),
+				"RCLONE_CONFIG_S3_PROVIDER": []byte("Minio"),
+				"RCLONE_CONFIG_S3_ENV_AUTH": []byte("true"),
+				"RCLONE_CONFIG_S3_ENDPOINT": []byte("https://minio:9000"),
+				"AWS_ENDPOINT_URL":          []byte("https://minio:9000"),
+				"S3_USE_HTTPS":              []byte("1"),
+			},
+		},
+		{
+			name:        "gcs credentials",
+			modelUri:    "gs://models/iris",
+			storage:     &servingv1.StorageSpec{Provider: servingv1.StorageProviderGCS, CredentialsSecretRef: credentialsRef},
+			credentials: &v1.Secret{Data: map[string][]byte{servingv1.StorageGCSCredentials: []byte("{}")}},
+			want: map[string][]byte{
+				"RCLONE_CONFIG_GS_TYPE":                        []byte("google cloud storage"),
+				"RCLONE_CONFIG_GS_ANONYMOUS":                   []byte("false"),
+				"RCLONE_CONFIG_GS_SERVICE_ACCOUNT_CREDENTIALS": []byte("{}"),
+			},
+		},
+		{
+			name:     "azure credentials",
+			modelUri: "https://account.blob.core.windows.net/models/iris",
+			storage:  &servingv1.StorageSpec{Provider: servingv1.StorageProviderAzure, CredentialsSecretRef: credentialsRef},
+			credentials: &v1.Secret{Data: map[string][]byte{
+				servingv1.StorageAzureSubscriptionID: []byte("subscription"),
+				servingv1.StorageAzureTenantID:       []byte("tenant"),
+				servingv1.StorageAzureClientID:       []byte("client"),
+				servingv1.StorageAzureClientSecret:   []byte("secret"),
+			}},
+			want: map[string][]byte{
+				servingv1.StorageAzureSubscriptionID: []byte("subscription"),
+				servingv1.StorageAzureTenantID:       []byte("tenant"),
+				servingv1.StorageAzureClientID:       []byte("client"),
+				servingv1.StorageAzureClientSecret:   []byte("secret"),
+			},
+		},
+		{
+			name:        "http credentials",
+			modelUri:    "https://models.example.com/iris",
+			storage:     &servingv1.StorageSpec{Provider: servingv1.StorageProviderHTTP, CredentialsSecretRef: credentialsRef},
+			credentials: &v1.Secret{},
+			wantReason:  reconcilers.ReasonUnsupportedStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := &servingv1.InferenceService{
+				ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default"},
+				Spec:       servingv1.InferenceServiceSpec{ModelUri: tt.modelUri, Storage: tt.storage},
+			}
+			componentMeta := metav1.ObjectMeta{Name: isvc.Name, Namespace: isvc.Namespace}
+			resources, err := (&Backend{}).BuildStorage(isvc, componentMeta, tt.credentials)
+			if tt.wantReason != "" {
+				if specErr, ok := reconcilers.AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+					t.Fatalf("BuildStorage() error = %v, want reason %s", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildStorage() error = %v", err)
+			}
+			if tt.want == nil {
+				if len(resources) != 0 {
+					t.Fatalf("BuildStorage() = %v, want no resources", resources)
+				}
+				return
+			}
+			if len(resources) != 1 {
+				t.Fatalf("BuildStorage() returned %d resources, want a secret", len(resources))
+			}
+			secret := resources[0].(*v1.Secret)
+			if secret.Name != "iris-seldon-storage" || secret.Namespace != "default" {
+				t.Errorf("secret = %s/%s, want default/iris-seldon-storage", secret.Namespace, secret.Name)
+			}
+			if !reflect.DeepEqual(secret.Data, tt.want) {
+				t.Errorf("secret data = %v, want %v", secret.Data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	v1 "k8s.io/api/core/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// transformerName is the name of the transformer graph node and of its container
+const transformerName = "transformer"
+
+// addTransformer puts the transformer of the spec in front of the model graph node. The
+// transformer container runs in the pod of the model server.
+func addTransformer(transformer *servingv1.TransformerSpec, model seldonv1.PredictiveUnit, podSpec *v1.PodSpec) seldonv1.PredictiveUnit {
+	container := v1.Container{
+		Name:  transformerName,
+		Image: transformer.Image,
+		Env:   transformer.Env,
+	}
+	if transformer.Resources != nil {
+		container.Resources = *transformer.Resources
+	}
+	podSpec.Containers = append(podSpec.Containers, container)
+
+	unitType := seldonv1.TRANSFORMER
+	return seldonv1.PredictiveUnit{
+		Name:     transformerName,
+		Type:     &unitType,
+		Children: []seldonv1.PredictiveUnit{model},
+	}
+}
+
+// hasTransformer returns true when the graph of the predictor starts with a transformer
+func hasTransformer(predictor *seldonv1.PredictorSpec) bool {
+	return predictor.Graph.Type != nil && *predictor.Graph.Type == seldonv1.TRANSFORMER
+}
@@ -0,0 +1,50 @@
+package seldon
+
+import (
+	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// variantLabel labels the predictors serving a model variant with the variant name
+const variantLabel = "serving.fuseml.suse/variant"
+
+// buildVariants returns a predictor for each model variant of the spec, receiving the
+// share of the traffic set by the variant weight
+func buildVariants(isvc *servingv1.InferenceService, storage modelStorage) ([]seldonv1.PredictorSpec, error) {
+	names := make(map[string]bool)
+	var weights int32
+	for _, variant := range isvc.Spec.Variants {
+		switch {
+		case variant.Name == shadowPredictorName:
+			return nil, reconcilers.NewSpecError(reconcilers.ReasonInvalidVariants,
+				"variant name %q is reserved for the shadow model by the %s backend", variant.Name, BackendName)
+		case names[variant.Name]:
+			return nil, reconcilers.NewSpecError(reconcilers.ReasonInvalidVariants,
+				"variant name %q is used by more than one variant", variant.Name)
+		}
+		names[variant.Name] = true
+		weights += variant.Weight
+	}
+	if weights != 100 {
+		return nil, reconcilers.NewSpecError(reconcilers.ReasonInvalidVariants,
+			"the weights of the variants sum to %d instead of 100", weights)
+	}
+
+	var predictors []seldonv1.PredictorSpec
+	for _, variant := range isvc.Spec.Variants {
+		framework := variant.Framework
+		if framework == "" {
+			framework = isvc.Spec.GetFramework()
+		}
+		predictor, err := buildPredictor(isvc, variant.Name, framework, variant.ModelUri, storage)
+		if err != nil {
+			return nil, err
+		}
+		predictor.Traffic = variant.Weight
+		predictor.Labels = map[string]string{variantLabel: variant.Name}
+		predictors = append(predictors, predictor)
+	}
+	return predictors, nil
+}
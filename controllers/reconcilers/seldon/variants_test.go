@@ -0,0 +1,93 @@
+package seldon
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+func TestBuildVariants(t *testing.T) {
+	tests := []struct {
+		name        string
+		variants    []servingv1.VariantSpec
+		wantTraffic map[string]int32
+		wantReason  string
+	}{
+		{
+			name: "valid",
+			variants: []servingv1.VariantSpec{
+				{Name: "a", ModelUri: testModelUri, Weight: 80},
+				{Name: "b", ModelUri: "s3://models/xgboost/iris", Framework: servingv1.FrameworkXGBoost, Weight: 20},
+			},
+			wantTraffic: map[string]int32{"a": 80, "b": 20},
+		},
+		{
+			name: "reserved shadow name",
+			variants: []servingv1.VariantSpec{
+				{Name: "a", ModelUri: testModelUri, Weight: 50},
+				{Name: shadowPredictorName, ModelUri: testModelUri, Weight: 50},
+			},
+			wantReason: reconcilers.ReasonInvalidVariants,
+		},
+		{
+			name: "duplicate names",
+			variants: []servingv1.VariantSpec{
+				{Name: "a", ModelUri: testModelUri, Weight: 50},
+				{Name: "a", ModelUri: testModelUri, Weight: 50},
+			},
+			wantReason: reconcilers.ReasonInvalidVariants,
+		},
+		{
+			name: "weights not summing to 100",
+			variants: []servingv1.VariantSpec{
+				{Name: "a", ModelUri: testModelUri, Weight: 50},
+				{Name: "b", ModelUri: testModelUri, Weight: 20},
+			},
+			wantReason: reconcilers.ReasonInvalidVariants,
+		},
+		{
+			name: "unsupported framework",
+			variants: []servingv1.VariantSpec{
+				{Name: "a", ModelUri: testModelUri, Framework: servingv1.FrameworkONNX, Weight: 100},
+			},
+			wantReason: reconcilers.ReasonUnsupportedFramework,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isvc := &servingv1.InferenceService{
+				ObjectMeta: metav1.ObjectMeta{Name: "iris", Namespace: "default"},
+				Spec: servingv1.InferenceServiceSpec{
+					Backend:   BackendName,
+					Framework: servingv1.FrameworkSKLearn,
+					Variants:  tt.variants,
+				},
+			}
+			predictors, err := buildVariants(isvc, modelStorage{initializerImage: defaultStorageInitializerImage})
+			if tt.wantReason != "" {
+				if specErr, ok := reconcilers.AsSpecError(err); !ok || specErr.Reason != tt.wantReason {
+					t.Fatalf("buildVariants() error = %v, want reason %s", err, tt.wantReason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildVariants() error = %v", err)
+			}
+			traffic := make(map[string]int32)
+			for _, predictor := range predictors {
+				traffic[predictor.Name] = predictor.Traffic
+				if label := predictor.Labels[variantLabel]; label != predictor.Name {
+					t.Errorf("predictor %s variant label = %q, want %q", predictor.Name, label, predictor.Name)
+				}
+			}
+			if !reflect.DeepEqual(traffic, tt.wantTraffic) {
+				t.Errorf("buildVariants() traffic = %v, want %v", traffic, tt.wantTraffic)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package reconcilers
+
+import (
+	"fmt"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// Reasons reported when the storage of an inference service cannot be used by a backend
+const (
+	ReasonUnsupportedStorage        = "UnsupportedStorage"
+	ReasonConflictingServiceAccount = "ConflictingServiceAccount"
+)
+
+// ReasonStorageFailed is reported while the storage resources cannot be created, which
+// is retried, e.g. until the credentials secret exists
+const ReasonStorageFailed = "StorageFailed"
+
+// StorageResourceName returns the name of the resources derived by the backend from
+// the storage credentials of the inference service
+func StorageResourceName(isvc *servingv1.InferenceService, backendName string) string {
+	return fmt.Sprintf("%s-%s-storage", isvc.Name, backendName)
+}
+
+// HasStorageCredentials returns true when the inference service references a secret
+// with the storage credentials
+func HasStorageCredentials(isvc *servingv1.InferenceService) bool {
+	return isvc.Spec.Storage != nil && isvc.Spec.Storage.CredentialsSecretRef != nil
+}
@@ -0,0 +1,36 @@
+package reconcilers
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// Validate checks that the backend selected by the inference service is registered and
+// able to deploy its spec with the operator configuration, by building the backend
+// resource without creating it
+func Validate(isvc *servingv1.InferenceService, config *servingv1.OperatorConfig) error {
+	backend, ok := Get(isvc.Spec.Backend)
+	if !ok {
+		return fmt.Errorf("backend %q is not registered, expected one of: %s",
+			isvc.Spec.Backend, strings.Join(Names(), ", "))
+	}
+
+	componentMeta := metav1.ObjectMeta{
+		Name:        isvc.Name,
+		Namespace:   isvc.Namespace,
+		Labels:      isvc.Labels,
+		Annotations: isvc.Annotations,
+	}
+	object, err := backend.Build(isvc, componentMeta, config)
+	if err != nil {
+		if specErr, ok := AsSpecError(err); ok {
+			return specErr
+		}
+		return err
+	}
+	return CheckImages(object, config)
+}
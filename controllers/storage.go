@@ -0,0 +1,206 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	servingv1 "fuseml.suse/api/v1"
+	"fuseml.suse/controllers/reconcilers"
+)
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;delete
+
+// storageResourceTypes lists the types of the resources the backends derive from the storage credentials
+var storageResourceTypes = []runtime.Object{&v1.Secret{}, &v1.ServiceAccount{}}
+
+// storageLabel labels the resources derived from the storage credentials with the name of
+// their inference service, so that the other secrets and service accounts are not reconciled
+const storageLabel = "serving.fuseml.suse/storage-for"
+
+// hasStorageLabel filters the events of the resources derived from the storage credentials
+var hasStorageLabel = predicate.NewPredicateFuncs(func(meta metav1.Object, _ runtime.Object) bool {
+	_, ok := meta.GetLabels()[storageLabel]
+	return ok
+})
+
+// credentialsSecretField indexes the inference services by their storage credentials secret
+const credentialsSecretField = "spec.storage.credentialsSecretRef.name"
+
+// credentialsSecretName returns the name of the storage credentials secret of the inference service
+func credentialsSecretName(obj runtime.Object) []string {
+	isvc := obj.(*servingv1.InferenceService)
+	if !reconcilers.HasStorageCredentials(isvc) {
+		return nil
+	}
+	return []string{isvc.Spec.Storage.CredentialsSecretRef.Name}
+}
+
+// enqueueForSecret maps the events of a secret to the inference service it is derived for,
+// or to the inference services using it as their storage credentials, so that the derived
+// resources follow the rotation of the credentials
+func (r *InferenceServiceReconciler) enqueueForSecret(obj handler.MapObject) []reconcile.Request {
+	if requests := enqueueByLabel(storageLabel)(obj); requests != nil {
+		return requests
+	}
+
+	services := &servingv1.InferenceServiceList{}
+	if err := r.List(context.TODO(), services, client.InNamespace(obj.Meta.GetNamespace()),
+		client.MatchingFields{credentialsSecretField: obj.Meta.GetName()}); err != nil {
+		r.Log.Error(err, "Failed to list the inference services using the storage credentials",
+			"namespace", obj.Meta.GetNamespace(), "secret", obj.Meta.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, service := range services.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: service.Name, Namespace: service.Namespace},
+		})
+	}
+	return requests
+}
+
+// reconcileStorage creates or updates the resources passing the storage credentials to the
+// backend. The defaulted inference service is the one the backend resource is built from.
+func (r *InferenceServiceReconciler) reconcileStorage(infSvc, defaulted *servingv1.InferenceService,
+	backend reconcilers.Backend, objectMeta metav1.ObjectMeta) error {
+	var credentials *v1.Secret
+	if reconcilers.HasStorageCredentials(defaulted) {
+		credentials = &v1.Secret{}
+		name := defaulted.Spec.Storage.CredentialsSecretRef.Name
+		namespacedName := types.NamespacedName{Name: name, Namespace: infSvc.Namespace}
+		if err := r.Get(context.TODO(), namespacedName, credentials); err != nil {
+			return errors.Wrapf(err, "fails to get storage credentials secret %q", name)
+		}
+	}
+
+	resources, err := backend.BuildStorage(defaulted, objectMeta, credentials)
+	if err != nil {
+		return errors.Wrapf(err, "fails to build %s storage resources", backend.Name())
+	}
+	if len(resources) == 0 {
+		// the storage settings were removed from the spec
+		return r.deleteStorageResources(infSvc, backend)
+	}
+
+	for _, desired := range resources {
+		// the labels of the backend resource are shared with the other resources
+		labels := make(map[string]string)
+		for k, v := range desired.GetLabels() {
+			labels[k] = v
+		}
+		labels[storageLabel] = infSvc.Name
+		desired.SetLabels(labels)
+		if err := controllerutil.SetControllerReference(infSvc, desired, r.Scheme); err != nil {
+			return errors.Wrapf(err, "fails to set owner reference for storage resource")
+		}
+		if err := r.reconcileStorageResource(desired); err != nil {
+			return errors.Wrapf(err, "fails to reconcile %s storage resource %q", backend.Name(), desired.GetName())
+		}
+	}
+	return nil
+}
+
+func (r *InferenceServiceReconciler) reconcileStorageResource(desired reconcilers.Object) error {
+	existing := desired.DeepCopyObject().(reconcilers.Object)
+	namespacedName := types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}
+	if err := r.Get(context.TODO(), namespacedName, existing); err != nil {
+		if !apierr.IsNotFound(err) {
+			return err
+		}
+		r.Log.Info("Creating storage resource", "namespace", desired.GetNamespace(), "name", desired.GetName())
+		return r.Create(context.TODO(), desired)
+	}
+
+	if !syncStorageResource(desired, existing) {
+		return nil
+	}
+	r.Log.Info("Updating storage resource", "namespace", desired.GetNamespace(), "name", desired.GetName())
+	return r.Update(context.TODO(), existing)
+}
+
+// syncStorageResource copies the fields set by the backend from the desired resource into
+// the existing one, and returns false when they already match
+func syncStorageResource(desired, existing reconcilers.Object) bool {
+	updated := false
+	if label := desired.GetLabels()[storageLabel]; existing.GetLabels()[storageLabel] != label {
+		// the resources created before the label was introduced are not watched without it
+		labels := existing.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[storageLabel] = label
+		existing.SetLabels(labels)
+		updated = true
+	}
+
+	switch desired := desired.(type) {
+	case *v1.Secret:
+		existing := existing.(*v1.Secret)
+		if equality.Semantic.DeepEqual(desired.Data, existing.Data) &&
+			equality.Semantic.DeepEqual(desired.Annotations, existing.Annotations) {
+			return updated
+		}
+		existing.Data = desired.Data
+		existing.Annotations = desired.Annotations
+	case *v1.ServiceAccount:
+		// the token controller adds secrets of its own to service accounts
+		existing := existing.(*v1.ServiceAccount)
+		for _, secret := range desired.Secrets {
+			if !containsSecretReference(existing.Secrets, secret.Name) {
+				existing.Secrets = append(existing.Secrets, secret)
+				updated = true
+			}
+		}
+		return updated
+	}
+	return true
+}
+
+func containsSecretReference(references []v1.ObjectReference, name string) bool {
+	for _, reference := range references {
+		if reference.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteStorageResources deletes the resources derived by the backend from the storage credentials
+func (r *InferenceServiceReconciler) deleteStorageResources(infSvc *servingv1.InferenceService, backend reconcilers.Backend) error {
+	name := reconcilers.StorageResourceName(infSvc, backend.Name())
+	for _, resourceType := range storageResourceTypes {
+		if err := r.deleteControlledResource(infSvc, resourceType.DeepCopyObject(), name); err != nil {
+			return errors.Wrapf(err, "fails to delete %s storage resource", backend.Name())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kubeflow/kfserving/pkg/credentials/s3"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	servingv1 "fuseml.suse/api/v1"
+)
+
+// withCredentials returns a function setting the storage credentials secret of an inference service
+func withCredentials(name string) func(isvc *servingv1.InferenceService) {
+	return func(isvc *servingv1.InferenceService) {
+		isvc.Spec.Storage = &servingv1.StorageSpec{
+			Provider:             servingv1.StorageProviderS3,
+			CredentialsSecretRef: &v1.LocalObjectReference{Name: name},
+		}
+	}
+}
+
+func newCredentials(name, accessKeyID string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Data: map[string][]byte{
+			servingv1.StorageS3AccessKeyID:     []byte(accessKeyID),
+			servingv1.StorageS3SecretAccessKey: []byte("secret"),
+		},
+	}
+}
+
+func TestEnqueueForSecret(t *testing.T) {
+	named := func(name string, mutate func(isvc *servingv1.InferenceService)) *servingv1.InferenceService {
+		return newTestService("kfserving", func(isvc *servingv1.InferenceService) {
+			isvc.Name = name
+			mutate(isvc)
+		})
+	}
+	elsewhere := named("elsewhere", withCredentials("credentials"))
+	elsewhere.Namespace = "other"
+	r := newTestReconciler(t,
+		named("iris", withCredentials("credentials")),
+		named("wine", withCredentials("credentials")),
+		named("digits", withCredentials("other-credentials")),
+		elsewhere,
+	)
+
+	tests := []struct {
+		name   string
+		secret metav1.ObjectMeta
+		want   []string
+	}{
+		{
+			name:   "derived secret",
+			secret: metav1.ObjectMeta{Name: "iris-seldon-storage", Namespace: "default", Labels: map[string]string{storageLabel: "iris"}},
+			want:   []string{"iris"},
+		},
+		{
+			name:   "storage credentials",
+			secret: metav1.ObjectMeta{Name: "credentials", Namespace: "default"},
+			want:   []string{"iris", "wine"},
+		},
+		{
+			name:   "unrelated secret",
+			secret: metav1.ObjectMeta{Name: "token", Namespace: "default"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &v1.Secret{ObjectMeta: tt.secret}
+			var got []string
+			for _, request := range r.enqueueForSecret(handler.MapObject{Meta: secret, Object: secret}) {
+				if request.Namespace != "default" {
+					t.Errorf("enqueueForSecret() enqueued %s", request.NamespacedName)
+				}
+				got = append(got, request.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("enqueueForSecret() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileStorageCredentialsRotation(t *testing.T) {
+	isvc := newTestService("kfserving", withCredentials("credentials"))
+	r := newTestReconciler(t, isvc, newCredentials("credentials", "key"))
+
+	derivedSecret := func() *v1.Secret {
+		secret := &v1.Secret{}
+		namespacedName := types.NamespacedName{Name: "iris-kfserving-storage", Namespace: "default"}
+		if err := r.Get(context.TODO(), namespacedName, secret); err != nil {
+			t.Fatalf("fails to get derived secret: %v", err)
+		}
+		return secret
+	}
+
+	if _, err := reconcileService(t, r, isvc); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	secret := derivedSecret()
+	if secret.Labels[storageLabel] != "iris" {
+		t.Errorf("derived secret labels = %v, want %s=iris", secret.Labels, storageLabel)
+	}
+	if got := string(secret.Data[s3.AWSAccessKeyIdName]); got != "key" {
+		t.Errorf("derived secret access key = %q, want key", got)
+	}
+
+	rotated := newCredentials("credentials", "rotated-key")
+	if err := r.Update(context.TODO(), rotated); err != nil {
+		t.Fatal(err)
+	}
+	requests := r.enqueueForSecret(handler.MapObject{Meta: rotated, Object: rotated})
+	if len(requests) != 1 || requests[0].Name != isvc.Name {
+		t.Fatalf("enqueueForSecret() = %v, want the inference service", requests)
+	}
+	if _, err := reconcileService(t, r, isvc); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if got := string(derivedSecret().Data[s3.AWSAccessKeyIdName]); got != "rotated-key" {
+		t.Errorf("derived secret access key = %q, want rotated-key", got)
+	}
+}
+
+func TestSyncStorageResource(t *testing.T) {
+	labels := map[string]string{storageLabel: "iris"}
+	data := map[string][]byte{s3.AWSAccessKeyIdName: []byte("key")}
+	tests := []struct {
+		name     string
+		existing *v1.Secret
+		want     bool
+	}{
+		{
+			name:     "up to date",
+			existing: &v1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Data: data},
+		},
+		{
+			name:     "created without the label",
+			existing: &v1.Secret{Data: data},
+			want:     true,
+		},
+		{
+			name:     "stale data",
+			existing: &v1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Data: data}
+			if got := syncStorageResource(desired, tt.existing); got != tt.want {
+				t.Errorf("syncStorageResource() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.existing.Labels, labels) || !reflect.DeepEqual(tt.existing.Data, data) {
+				t.Errorf("existing secret = %v %v, want %v %v", tt.existing.Labels, tt.existing.Data, labels, data)
+			}
+		})
+	}
+}
@@ -20,8 +20,6 @@ import (
 	"flag"
 	"os"
 
-	kfservingv1 "github.com/kubeflow/kfserving/pkg/apis/serving/v1beta1"
-	seldonv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -35,6 +33,10 @@ import (
 
 	servingv1 "fuseml.suse/api/v1"
 	v1controller "fuseml.suse/controllers"
+	"fuseml.suse/controllers/reconcilers"
+	// Serving backends register themselves with the reconcilers registry
+	_ "fuseml.suse/controllers/reconcilers/kfserving"
+	_ "fuseml.suse/controllers/reconcilers/seldon"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -49,7 +51,12 @@ func init() {
 
 func main() {
 	var metricsAddr string
+	var configFile string
+	var loggingURL string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&configFile, "config", "", "The operator configuration file with the inference service defaults.")
+	flag.StringVar(&loggingURL, "default-logging-url", "",
+		"The sink receiving the payloads captured for the inference services that do not set one. Overrides loggingUrl of the operator configuration.")
 	flag.Parse()
 	logf.SetLogger(zap.New())
 	log := logf.Log.WithName("entrypoint")
@@ -62,6 +69,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	log.Info("Loading operator configuration", "config", configFile)
+	operatorConfig, err := servingv1.LoadOperatorConfig(configFile)
+	if err != nil {
+		log.Error(err, "unable to load operator configuration")
+		os.Exit(1)
+	}
+	if loggingURL != "" {
+		operatorConfig.LoggingURL = loggingURL
+	}
+
 	// Create a new Cmd to provide shared dependencies and start components
 	log.Info("Setting up manager")
 	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: metricsAddr, Port: 9443})
@@ -78,16 +95,12 @@ func main() {
 		os.Exit(1)
 	}
 
-	log.Info("Setting up KFServing v1beta1 scheme")
-	if err := kfservingv1.AddToScheme(mgr.GetScheme()); err != nil {
-		log.Error(err, "unable to add KFServing v1beta1 to scheme")
-		os.Exit(1)
-	}
-
-	log.Info("Setting up SeldonCore v1 scheme")
-	if err := seldonv1.AddToScheme(mgr.GetScheme()); err != nil {
-		log.Error(err, "unable to add SeldonCore v1 to scheme")
-		os.Exit(1)
+	for _, backend := range reconcilers.Backends() {
+		log.Info("Setting up backend scheme", "backend", backend.Name())
+		if err := backend.AddToScheme(mgr.GetScheme()); err != nil {
+			log.Error(err, "unable to add backend to scheme", "backend", backend.Name())
+			os.Exit(1)
+		}
 	}
 
 	log.Info("Setting up core scheme")
@@ -111,11 +124,19 @@ func main() {
 		Scheme: mgr.GetScheme(),
 		Recorder: eventBroadcaster.NewRecorder(
 			mgr.GetScheme(), v1.EventSource{Component: "v1controller"}),
+		Config: operatorConfig,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "v1controller", "InferenceService")
 		os.Exit(1)
 	}
 
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		setupLog.Info("Setting up webhooks")
+		if err = (&servingv1.InferenceService{}).SetupWebhookWithManager(mgr, operatorConfig, reconcilers.Validate); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "InferenceService")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("Starting manager")